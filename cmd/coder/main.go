@@ -39,7 +39,7 @@ func main() {
 	app := cmd.Make()
 	app.Version = fmt.Sprintf("%s %s %s/%s", version.Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
 
-	if err := app.ExecuteContext(ctx); err != nil {
+	if err := cmd.ExecuteContext(ctx, app); err != nil {
 		clog.Log(err)
 		cancel()
 		restoreTerminal()
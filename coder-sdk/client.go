@@ -13,18 +13,21 @@ const Me = "me"
 type Client struct {
 	BaseURL *url.URL
 	Token   string
+
+	// Transport sends the HTTP requests, including the websocket handshakes.
+	// When nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
 }
 
 // newHTTPClient creates a default underlying http client and sets the auth cookie.
 //
-// NOTE: As we do not specify a custom transport, the default one from the stdlib will be used,
-//       resulting in a persistent connection pool.
-//       We do not set a timeout here as it could cause issue with the websocket.
-//       The caller is expected to set it when needed.
+// NOTE: Unless Transport is set, the default one from the stdlib will be used, resulting in a
+// persistent connection pool. We do not set a timeout here as it could cause issue with the websocket.
+// The caller is expected to set it when needed.
 //
 // WARNING: If the caller sets a custom transport to set TLS settings or a custom CA, the default
-//          pool will not be used and it might result in a new dns lookup/tls session/socket begin
-//          established each time.
+// pool will not be used and it might result in a new dns lookup/tls session/socket begin
+// established each time.
 func (c Client) newHTTPClient() (*http.Client, error) {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
@@ -40,5 +43,5 @@ func (c Client) newHTTPClient() (*http.Client, error) {
 		Secure:   c.BaseURL.Scheme == "https",
 	}})
 
-	return &http.Client{Jar: jar}, nil
+	return &http.Client{Jar: jar, Transport: c.Transport}, nil
 }
@@ -0,0 +1,47 @@
+package coder_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"cdr.dev/slog/sloggers/slogtest/assert"
+
+	"cdr.dev/coder-cli/coder-sdk"
+)
+
+func TestClientProxy(t *testing.T) {
+	t.Parallel()
+
+	// The proxy answers in place of the unresolvable Coder URL.
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "proxied host", "coder.invalid", r.Host)
+		assert.Equal(t, "proxied path", "/api/environments/env-id/devurls", r.URL.Path)
+		_ = json.NewEncoder(w).Encode([]coder.DevURL{{ID: "url-id", Port: 8080}}) // Best effort.
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	assert.Success(t, "parse proxy url", err)
+
+	var proxied int
+	u, err := url.Parse("http://coder.invalid")
+	assert.Success(t, "parse coder url", err)
+	client := &coder.Client{
+		BaseURL: u,
+		Token:   "fake-session-token",
+		Transport: &http.Transport{
+			Proxy: func(r *http.Request) (*url.URL, error) {
+				proxied++
+				return proxyURL, nil
+			},
+		},
+	}
+
+	devURLs, err := client.DevURLs(context.Background(), "env-id")
+	assert.Success(t, "list devurls", err)
+	assert.Equal(t, "devurls", []coder.DevURL{{ID: "url-id", Port: 8080}}, devURLs)
+	assert.Equal(t, "proxied requests", 1, proxied)
+}
@@ -4,16 +4,62 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// DevURL access levels, in increasing order of exposure.
+const (
+	DevURLAccessPrivate = "PRIVATE"
+	DevURLAccessOrg     = "ORG"
+	DevURLAccessAuthed  = "AUTHED"
+	DevURLAccessPublic  = "PUBLIC"
 )
 
+// DevURLAccessLevels lists the valid devurl access levels.
+var DevURLAccessLevels = []string{DevURLAccessPrivate, DevURLAccessOrg, DevURLAccessAuthed, DevURLAccessPublic}
+
+// ParseAccessLevel normalizes the given devurl access level, in any case, to its canonical
+// uppercase value.
+func ParseAccessLevel(level string) (string, error) {
+	canonical := strings.ToUpper(strings.TrimSpace(level))
+	for _, l := range DevURLAccessLevels {
+		if l == canonical {
+			return l, nil
+		}
+	}
+	return "", xerrors.Errorf("invalid access level %q, expected one of %q", level, DevURLAccessLevels)
+}
+
 // DevURL is the parsed json response record for a devURL from cemanager.
 type DevURL struct {
-	ID     string `json:"id"     table:"ID"`
-	URL    string `json:"url"    table:"URL"`
-	Port   int    `json:"port"   table:"Port"`
-	Access string `json:"access" table:"Access"`
-	Name   string `json:"name"   table:"Name"`
-	Scheme string `json:"scheme" table:"-"`
+	ID     string `json:"id"     yaml:"id"     table:"-"`
+	URL    string `json:"url"    yaml:"url"    table:"URL"`
+	Port   int    `json:"port"   yaml:"port"   table:"Port,right"`
+	Access string `json:"access" yaml:"access" table:"Access"`
+	Name   string `json:"name"   yaml:"name"   table:"-"`
+	Scheme string `json:"scheme" yaml:"scheme" table:"-"`
+	// Wildcard devurls also route every subdomain of their hostname to the port.
+	Wildcard bool `json:"wildcard,omitempty" yaml:"wildcard,omitempty" table:"-"`
+	// CreatedAt and UpdatedAt are nil when the server doesn't report them.
+	CreatedAt *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty" table:"-"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" yaml:"updated_at,omitempty" table:"-"`
+}
+
+// DevURLs fetches the devurls of the given environment, following the pagination of the list if any.
+func (c Client) DevURLs(ctx context.Context, envID string) ([]DevURL, error) {
+	var devURLs []DevURL
+	err := c.requestPages(ctx, "/api/environments/"+envID+"/devurls", func() interface{} {
+		return &[]DevURL{}
+	}, func(page interface{}) {
+		devURLs = append(devURLs, *page.(*[]DevURL)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return devURLs, nil
 }
 
 type delDevURLRequest struct {
@@ -38,6 +84,10 @@ type CreateDevURLReq struct {
 	Access string `json:"access"`
 	Name   string `json:"name"`
 	Scheme string `json:"scheme"`
+	// AutoPort asks the cemanager to allocate a free port, in which case Port is ignored.
+	AutoPort bool `json:"auto_port,omitempty"`
+	// Wildcard asks the cemanager to route every subdomain of the devurl hostname to the port.
+	Wildcard bool `json:"wildcard,omitempty"`
 }
 
 // CreateDevURL inserts a new devurl for the authenticated user.
@@ -45,6 +95,18 @@ func (c Client) CreateDevURL(ctx context.Context, envID string, req CreateDevURL
 	return c.requestBody(ctx, http.MethodPost, "/api/private/environments/"+envID+"/devurls", req, nil)
 }
 
+// CreateDevURLAutoPort inserts a new devurl on a free port allocated by the cemanager
+// and returns the created devurl.
+func (c Client) CreateDevURLAutoPort(ctx context.Context, envID string, req CreateDevURLReq) (*DevURL, error) {
+	req.Port, req.AutoPort = 0, true
+
+	var devURL DevURL
+	if err := c.requestBody(ctx, http.MethodPost, "/api/private/environments/"+envID+"/devurls", req, &devURL); err != nil {
+		return nil, err
+	}
+	return &devURL, nil
+}
+
 // PutDevURLReq defines the request parameters for overwriting a DevURL.
 type PutDevURLReq CreateDevURLReq
 
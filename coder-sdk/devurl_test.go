@@ -0,0 +1,90 @@
+package coder_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"cdr.dev/slog/sloggers/slogtest/assert"
+
+	"cdr.dev/coder-cli/coder-sdk"
+)
+
+func TestDevURLs(t *testing.T) {
+	t.Parallel()
+
+	const token = "fake-session-token"
+	want := []coder.DevURL{{ID: "url-id", URL: "web.coder.com", Port: 8080, Access: "PRIVATE", Name: "web", Scheme: "http"}}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "request path", "/api/environments/env-id/devurls", r.URL.Path)
+		assert.Equal(t, "no session token in query", "", r.URL.Query().Get("session_token"))
+
+		cookie, err := r.Cookie("session_token")
+		assert.Success(t, "session cookie", err)
+		assert.Equal(t, "session cookie value", token, cookie.Value)
+
+		_ = json.NewEncoder(w).Encode(want) // Best effort.
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	assert.Success(t, "parse test server url", err)
+	client := &coder.Client{BaseURL: u, Token: token}
+
+	devURLs, err := client.DevURLs(context.Background(), "env-id")
+	assert.Success(t, "list devurls", err)
+	assert.Equal(t, "devurls", want, devURLs)
+}
+
+func TestDevURLsPagination(t *testing.T) {
+	t.Parallel()
+
+	pages := map[string][]coder.DevURL{
+		"":  {{ID: "url-1", Port: 8080}, {ID: "url-2", Port: 9090}},
+		"2": {{ID: "url-3", Port: 3000}},
+	}
+
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "request path", "/api/environments/env-id/devurls", r.URL.Path)
+		page := r.URL.Query().Get("page")
+		if page == "" {
+			w.Header().Set("Link", `<`+srvURL+`/api/environments/env-id/devurls?page=2>; rel="next", <`+srvURL+`/api/environments/env-id/devurls?page=2>; rel="last"`)
+		}
+		_ = json.NewEncoder(w).Encode(pages[page]) // Best effort.
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	u, err := url.Parse(srv.URL)
+	assert.Success(t, "parse test server url", err)
+	client := &coder.Client{BaseURL: u, Token: "fake-session-token"}
+
+	devURLs, err := client.DevURLs(context.Background(), "env-id")
+	assert.Success(t, "list devurls", err)
+	assert.Equal(t, "devurls", append(pages[""], pages["2"]...), devURLs)
+}
+
+func TestParseAccessLevel(t *testing.T) {
+	t.Parallel()
+
+	for input, want := range map[string]string{
+		"private":  coder.DevURLAccessPrivate,
+		"Org":      coder.DevURLAccessOrg,
+		" AUTHED ": coder.DevURLAccessAuthed,
+		"public":   coder.DevURLAccessPublic,
+	} {
+		level, err := coder.ParseAccessLevel(input)
+		assert.Success(t, "parse "+input, err)
+		assert.Equal(t, "level of "+input, want, level)
+	}
+
+	for _, input := range []string{"", "everyone", "privat"} {
+		_, err := coder.ParseAccessLevel(input)
+		assert.Error(t, "parse "+input, err)
+	}
+}
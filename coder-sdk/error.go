@@ -3,7 +3,11 @@ package coder
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/xerrors"
 )
@@ -27,17 +31,41 @@ type apiErrorMsg struct {
 	Msg string `json:"msg"`
 }
 
+const (
+	// maxErrorBodyRead is the maximum number of bytes read from the payload of an error response.
+	maxErrorBodyRead = 4 << 10
+	// maxErrorBodyLen is the maximum number of bytes of a non-json error payload included in the error message.
+	maxErrorBodyLen = 256
+)
+
 // HTTPError represents an error from the Coder API.
 type HTTPError struct {
 	*http.Response
+
+	// body holds the beginning of the response payload, as the response body is closed
+	// by the time the error is reported.
+	body []byte
 }
 
 func (e *HTTPError) Error() string {
 	var msg apiError
 	// Try to decode the payload as an error, if it fails or if there is no error message,
-	// return the response URL with the status.
-	if err := json.NewDecoder(e.Response.Body).Decode(&msg); err != nil || msg.Err.Msg == "" {
-		return fmt.Sprintf("%s: %d %s", e.Request.URL, e.StatusCode, e.Status)
+	// return the response URL with the status, along with the raw payload if any.
+	if err := json.Unmarshal(e.body, &msg); err != nil || msg.Err.Msg == "" {
+		status := fmt.Sprintf("%s: %d %s", e.Request.URL, e.StatusCode, e.Status)
+		body := strings.TrimSpace(string(e.body))
+		if body == "" {
+			return status
+		}
+		if len(body) > maxErrorBodyLen {
+			// Cut at the start of a rune to keep the message valid UTF-8.
+			end := maxErrorBodyLen
+			for end > 0 && !utf8.RuneStart(body[end]) {
+				end--
+			}
+			body = body[:end] + "..."
+		}
+		return status + ": " + body
 	}
 
 	// If the payload was a in the expected error format with a message, include it.
@@ -45,5 +73,7 @@ func (e *HTTPError) Error() string {
 }
 
 func bodyError(resp *http.Response) error {
-	return &HTTPError{Response: resp}
+	// Best effort, an unreadable payload is reported without details.
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyRead))
+	return &HTTPError{Response: resp, body: body}
 }
@@ -0,0 +1,57 @@
+package coder_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"cdr.dev/slog/sloggers/slogtest/assert"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/coder-cli/coder-sdk"
+)
+
+func TestHTTPErrorBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "api error", body: `{"error":{"msg":"environment not found"}}`, want: "environment not found"},
+		{name: "plain text", body: "session expired\n", want: "session expired"},
+		{name: "empty", body: "", want: "404 Not Found"},
+		{name: "truncated", body: strings.Repeat("x", 1000), want: strings.Repeat("x", 256) + "..."},
+		{name: "truncated rune", body: strings.Repeat("x", 255) + "é" + strings.Repeat("x", 100), want: ": " + strings.Repeat("x", 255) + "..."},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(test.body)) // Best effort.
+			}))
+			defer srv.Close()
+
+			u, err := url.Parse(srv.URL)
+			assert.Success(t, "parse test server url", err)
+			client := &coder.Client{BaseURL: u, Token: "fake-session-token"}
+
+			err = client.DeleteDevURL(context.Background(), "env-id", "url-id")
+			assert.Error(t, "delete devurl", err)
+
+			var httpErr *coder.HTTPError
+			assert.True(t, "is http error", xerrors.As(err, &httpErr))
+			assert.Equal(t, "status code", http.StatusNotFound, httpErr.StatusCode)
+			assert.True(t, "error message "+err.Error(), strings.HasSuffix(err.Error(), test.want))
+			assert.True(t, "valid utf-8", utf8.ValidString(err.Error()))
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"golang.org/x/xerrors"
 )
@@ -44,7 +46,7 @@ func (c Client) request(ctx context.Context, method, path string, in interface{}
 func (c Client) requestBody(ctx context.Context, method, path string, in, out interface{}) error {
 	resp, err := c.request(ctx, method, path, in)
 	if err != nil {
-		return xerrors.Errorf("Execute request: %q", err)
+		return xerrors.Errorf("Execute request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }() // Best effort, likely connection dropped.
 
@@ -62,3 +64,72 @@ func (c Client) requestBody(ctx context.Context, method, path string, in, out in
 	}
 	return nil
 }
+
+// requestPages GETs the given path and the pages following it, as given by the "next" relation of the
+// Link response header. Each page is decoded into the value returned by newPage, then passed to add.
+func (c Client) requestPages(ctx context.Context, path string, newPage func() interface{}, add func(page interface{})) error {
+	seen := map[string]bool{}
+	for path != "" {
+		if seen[path] {
+			return xerrors.Errorf("pagination loop at %q", path)
+		}
+		seen[path] = true
+
+		resp, err := c.request(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return xerrors.Errorf("Execute request: %w", err)
+		}
+		if resp.StatusCode > 299 {
+			err := fmt.Errorf("unexpected status code %d: %w", resp.StatusCode, bodyError(resp))
+			_ = resp.Body.Close() // Best effort, likely connection dropped.
+			return err
+		}
+
+		page := newPage()
+		err = json.NewDecoder(resp.Body).Decode(page)
+		_ = resp.Body.Close() // Best effort, likely connection dropped.
+		if err != nil {
+			return xerrors.Errorf("decode response body: %w", err)
+		}
+		add(page)
+
+		if path, err = c.nextPage(resp.Header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextPage extracts the path of the "next" relation from a Link header, e.g.
+// `<https://coder.domain/api/items?page=2>; rel="next"`. An empty path is returned on the last page.
+func (c Client) nextPage(header http.Header) (string, error) {
+	for _, link := range header.Values("Link") {
+		for _, part := range strings.Split(link, ",") {
+			fields := strings.Split(part, ";")
+			target := strings.TrimSpace(fields[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			var next bool
+			for _, param := range fields[1:] {
+				if strings.TrimSpace(param) == `rel="next"` {
+					next = true
+				}
+			}
+			if !next {
+				continue
+			}
+
+			u, err := c.BaseURL.Parse(strings.Trim(target, "<>"))
+			if err != nil {
+				return "", xerrors.Errorf("parse next page url: %w", err)
+			}
+			if u.Host != c.BaseURL.Host {
+				return "", xerrors.Errorf("next page %q is not on %q", u, c.BaseURL.Host)
+			}
+			// Paths are relative to the base url, which may have a path prefix.
+			return strings.TrimPrefix((&url.URL{Path: u.Path, RawQuery: u.RawQuery}).RequestURI(), c.BaseURL.Path), nil
+		}
+	}
+	return "", nil
+}
@@ -13,6 +13,8 @@ type APIToken struct {
 	Application bool      `json:"application"`
 	UserID      string    `json:"user_id"`
 	LastUsed    time.Time `json:"last_used"`
+	// ExpiresAt is nil when the server doesn't report the expiry of the token.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 // CreateAPITokenReq defines the paramemters for creating a new APIToken.
@@ -18,7 +18,11 @@ func (c Client) dialWebsocket(ctx context.Context, path string) (*websocket.Conn
 	}
 	url.Path = path
 
-	conn, resp, err := websocket.Dial(ctx, url.String(), &websocket.DialOptions{HTTPHeader: http.Header{"Session-Token": {c.Token}}})
+	opts := &websocket.DialOptions{HTTPHeader: http.Header{"Session-Token": {c.Token}}}
+	if c.Transport != nil {
+		opts.HTTPClient = &http.Client{Transport: c.Transport}
+	}
+	conn, resp, err := websocket.Dial(ctx, url.String(), opts)
 	if err != nil {
 		if resp != nil {
 			return nil, bodyError(resp)
@@ -3,9 +3,14 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/xerrors"
 
@@ -22,24 +27,12 @@ var errNeedLogin = clog.Fatal(
 
 const tokenEnv = "CODER_TOKEN"
 const urlEnv = "CODER_URL"
+const tokenFileEnv = "CODER_TOKEN_FILE"
 
 func newClient(ctx context.Context) (*coder.Client, error) {
-	var (
-		err          error
-		sessionToken = os.Getenv(tokenEnv)
-		rawURL       = os.Getenv(urlEnv)
-	)
-
-	if sessionToken == "" || rawURL == "" {
-		sessionToken, err = config.Session.Read()
-		if err != nil {
-			return nil, errNeedLogin
-		}
-
-		rawURL, err = config.URL.Read()
-		if err != nil {
-			return nil, errNeedLogin
-		}
+	rawURL, sessionToken, err := sessionCredentials()
+	if err != nil {
+		return nil, err
 	}
 
 	u, err := url.Parse(rawURL)
@@ -47,9 +40,17 @@ func newClient(ctx context.Context) (*coder.Client, error) {
 		return nil, xerrors.Errorf("url malformed: %w try running \"coder login\" with a valid URL", err)
 	}
 
+	transport, err := newTransport()
+	if err != nil {
+		return nil, err
+	}
+	if verbose {
+		transport = &loggingTransport{base: transport}
+	}
 	c := &coder.Client{
-		BaseURL: u,
-		Token:   sessionToken,
+		BaseURL:   u,
+		Token:     sessionToken,
+		Transport: transport,
 	}
 
 	apiVersion, err := c.APIVersion(ctx)
@@ -63,6 +64,10 @@ func newClient(ctx context.Context) (*coder.Client, error) {
 		return nil, err
 	}
 
+	if tokenExpiryCheck {
+		tokenExpiryOnce.Do(func() { warnTokenExpiry(ctx, c) })
+	}
+
 	if !version.VersionsMatch(apiVersion) {
 		clog.LogWarn(
 			"version mismatch detected",
@@ -74,3 +79,95 @@ func newClient(ctx context.Context) (*coder.Client, error) {
 
 	return c, nil
 }
+
+// tokenExpiryWarning is how long before its expiry the session token is warned about.
+const tokenExpiryWarning = time.Hour
+
+// tokenExpiryCheck is set by the commands which may run batches of operations, such as the urls commands,
+// to warn about the expiry of the session token. The expiry is only looked up by the first client of the process.
+var (
+	tokenExpiryCheck bool
+	tokenExpiryOnce  sync.Once
+)
+
+// sessionTokenRx matches the session tokens issued by Coder, made of a 10 characters ID and
+// a 22 characters secret separated by a dash.
+var sessionTokenRx = regexp.MustCompile(`^([a-zA-Z0-9]{10})-[a-zA-Z0-9]{22}$`)
+
+// warnTokenExpiry warns when the session token of c is about to expire, so that it can be renewed
+// before a batch of operations fails halfway. It's a no-op when the ID of the token can't be parsed
+// from it, or its expiry can't be looked up.
+func warnTokenExpiry(ctx context.Context, c *coder.Client) {
+	match := sessionTokenRx.FindStringSubmatch(c.Token)
+	if match == nil {
+		return
+	}
+	token, err := c.APITokenByID(ctx, coder.Me, match[1])
+	if err != nil || token.ExpiresAt == nil {
+		return
+	}
+	if left := time.Until(*token.ExpiresAt); left < tokenExpiryWarning {
+		clog.LogWarn(
+			fmt.Sprintf("the session token expires in %s", left.Round(time.Minute)), clog.BlankLine,
+			clog.Tipf(`run "coder login" to renew it`),
+		)
+	}
+}
+
+// sessionCredentials returns the access URL and session token used to authenticate.
+//
+// The token file given by --token-file or CODER_TOKEN_FILE takes precedence, with the URL read from
+// CODER_URL or the one saved by "coder login". Otherwise, the CODER_URL and CODER_TOKEN environment
+// variables take precedence over the credentials saved by "coder login", which allows running
+// unattended. These variables are only used together, as sending the saved token to another URL would leak it.
+func sessionCredentials() (rawURL, sessionToken string, err error) {
+	rawURL, sessionToken = strings.TrimSpace(os.Getenv(urlEnv)), strings.TrimSpace(os.Getenv(tokenEnv))
+
+	path := tokenFile
+	if path == "" {
+		path = os.Getenv(tokenFileEnv)
+	}
+	if path != "" {
+		if sessionToken != "" {
+			clog.LogWarn(fmt.Sprintf("both a token file and %s are set, using the token file %q", tokenEnv, path))
+		}
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", "", xerrors.Errorf("read token file: %w", err)
+		}
+		if sessionToken = strings.TrimSpace(string(raw)); sessionToken == "" {
+			return "", "", clog.Error(fmt.Sprintf("token file %q is empty", path))
+		}
+		if rawURL != "" {
+			return rawURL, sessionToken, nil
+		}
+		if rawURL, err = config.URL.Read(); err != nil {
+			return "", "", errNeedLogin
+		}
+		return rawURL, sessionToken, nil
+	}
+
+	if rawURL != "" && sessionToken != "" {
+		return rawURL, sessionToken, nil
+	}
+	if rawURL != "" || sessionToken != "" {
+		set, unset := urlEnv, tokenEnv
+		if rawURL == "" {
+			set, unset = tokenEnv, urlEnv
+		}
+		clog.LogWarn(
+			fmt.Sprintf("ignoring %s as %s is not set", set, unset),
+			clog.Tipf("set both %s and %s to authenticate from the environment", urlEnv, tokenEnv),
+		)
+	}
+
+	sessionToken, err = config.Session.Read()
+	if err != nil {
+		return "", "", errNeedLogin
+	}
+	rawURL, err = config.URL.Read()
+	if err != nil {
+		return "", "", errNeedLogin
+	}
+	return rawURL, sessionToken, nil
+}
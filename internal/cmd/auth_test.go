@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cdr.dev/slog/sloggers/slogtest/assert"
+
+	"cdr.dev/coder-cli/coder-sdk"
+)
+
+func TestNewClientFromEnv(t *testing.T) {
+	f := newFakeCemanager(t)
+	setFakeEnv(t, "CODER_URL", " "+f.URL+"\n")
+	setFakeEnv(t, "CODER_TOKEN", "fake-token\n")
+
+	client, err := newClient(context.Background())
+	assert.Success(t, "new client", err)
+	assert.Equal(t, "base url", f.URL, client.BaseURL.String())
+	assert.Equal(t, "token", "fake-token", client.Token)
+}
+
+func TestTokenExpiryWarning(t *testing.T) {
+	f := newFakeCemanager(t)
+	setFakeEnv(t, "CODER_TOKEN", fakeSessionToken)
+	tokenExpiryCheck = true
+	defer func() { tokenExpiryCheck, tokenExpiryOnce = false, sync.Once{} }()
+	newClientLogs := func() string {
+		// Look the expiry up again, as it is only checked once per process.
+		tokenExpiryOnce = sync.Once{}
+		return captureStderr(t, func() {
+			_, err := newClient(context.Background())
+			assert.Success(t, "new client", err)
+		})
+	}
+
+	assert.True(t, "unknown expiry", !strings.Contains(newClientLogs(), "session token expires"))
+
+	expiresAt := time.Now().Add(30 * time.Minute)
+	f.mu.Lock()
+	f.tokenExpiresAt = &expiresAt
+	f.mu.Unlock()
+	assert.True(t, "close expiry", strings.Contains(newClientLogs(), "the session token expires in 30m"))
+
+	cached := captureStderr(t, func() {
+		_, err := newClient(context.Background())
+		assert.Success(t, "new client", err)
+	})
+	assert.True(t, "checked once per process", !strings.Contains(cached, "session token expires"))
+
+	setFakeEnv(t, "CODER_TOKEN", "fake-token")
+	assert.True(t, "unparseable token", !strings.Contains(newClientLogs(), "session token expires"))
+	setFakeEnv(t, "CODER_TOKEN", fakeSessionToken)
+
+	tokenExpiryCheck = false
+	assert.True(t, "commands without the check", !strings.Contains(newClientLogs(), "session token expires"))
+	tokenExpiryCheck = true
+
+	f.mu.Lock()
+	expiresAt = time.Now().Add(24 * time.Hour)
+	f.mu.Unlock()
+	assert.True(t, "distant expiry", !strings.Contains(newClientLogs(), "session token expires"))
+}
+
+func TestSessionCredentialsPartialEnv(t *testing.T) {
+	setFakeEnv(t, "CODER_URL", "https://coder.com")
+	setFakeEnv(t, "CODER_TOKEN", "")
+
+	reader, writer, err := os.Pipe()
+	assert.Success(t, "create pipe", err)
+
+	//! clearly not thread safe
+	stderr := os.Stderr
+	os.Stderr = writer
+	defer func() { os.Stderr = stderr }()
+
+	// NOTE: The result depends on the credentials saved on this machine, only the
+	// environment must be ignored.
+	rawURL, _, _ := sessionCredentials()
+	writer.Close()
+
+	output, err := ioutil.ReadAll(reader)
+	assert.Success(t, "read all stderr output", err)
+	assert.True(t, "env url is ignored", rawURL != "https://coder.com")
+	assert.True(t, "warns about the missing token", strings.Contains(string(output), "ignoring CODER_URL as CODER_TOKEN is not set"))
+}
+
+func TestSessionCredentialsTokenFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coder-cli-token")
+	assert.Success(t, "create temp dir", err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	assert.Success(t, "write token file", ioutil.WriteFile(path, []byte("file-token\n"), 0600))
+	setFakeEnv(t, "CODER_URL", "https://coder.com")
+	setFakeEnv(t, "CODER_TOKEN", "env-token")
+
+	t.Run("env", func(t *testing.T) {
+		setFakeEnv(t, "CODER_TOKEN_FILE", path)
+
+		rawURL, sessionToken, err := sessionCredentials()
+		assert.Success(t, "session credentials", err)
+		assert.Equal(t, "url", "https://coder.com", rawURL)
+		assert.Equal(t, "token", "file-token", sessionToken)
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		setFakeEnv(t, "CODER_TOKEN_FILE", filepath.Join(dir, "missing"))
+		tokenFile = path
+		defer func() { tokenFile = "" }()
+
+		_, sessionToken, err := sessionCredentials()
+		assert.Success(t, "session credentials", err)
+		assert.Equal(t, "token", "file-token", sessionToken)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		empty := filepath.Join(dir, "empty")
+		assert.Success(t, "write token file", ioutil.WriteFile(empty, []byte(" \n"), 0600))
+		setFakeEnv(t, "CODER_TOKEN_FILE", empty)
+
+		_, _, err := sessionCredentials()
+		assert.Error(t, "empty token file", err)
+	})
+}
+
+func TestCommandTimeout(t *testing.T) {
+	// The server never answers, like a hung authentication.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	setFakeEnv(t, "CODER_URL", srv.URL)
+	setFakeEnv(t, "CODER_TOKEN", "fake-token")
+
+	err := runCmd(t, "envs", "ls", "--command-timeout", "50ms")
+	assert.Error(t, "command times out", err)
+	assert.True(t, "timeout error", strings.Contains(err.Error(), "operation timed out after 50ms"))
+
+	err = runCmd(t, "urls", "ls", "env1", "--command-timeout", "-1s")
+	assert.Error(t, "negative timeout", err)
+}
+
+func TestProxyFlag(t *testing.T) {
+	// The fake serves the requests proxied to the unresolvable Coder URL.
+	f := newFakeCemanager(t, coder.DevURL{ID: "web-id", Port: 8080, Access: "PRIVATE"})
+	setFakeEnv(t, "CODER_URL", "http://coder.invalid")
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "--proxy", f.URL, "-o", "json")
+	})
+	assert.Success(t, "list devurls through the proxy", err)
+	assert.True(t, "listed devurls", strings.Contains(output, "web-id"))
+
+	err = runCmd(t, "urls", "ls", "env1", "--proxy", "proxy.corp.com:3128")
+	assert.Error(t, "invalid proxy", err)
+	// Unlike runCmd, newClient doesn't reset the global flags.
+	proxy = ""
+}
+
+func TestInsecureFlag(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	setFakeEnv(t, "CODER_URL", srv.URL)
+	setFakeEnv(t, "CODER_TOKEN", "fake-token")
+
+	_, err := newClient(context.Background())
+	assert.Error(t, "self-signed certificate", err)
+
+	insecure = true
+	defer func() { insecure = false }()
+	var client *coder.Client
+	stderr := captureStderr(t, func() {
+		client, err = newClient(context.Background())
+	})
+	assert.Success(t, "skip the verification", err)
+	assert.True(t, "client", client != nil)
+	assert.True(t, "warns about the verification", strings.Contains(stderr, "skipping the verification of the TLS certificate"))
+}
+
+func TestCACertFlag(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	setFakeEnv(t, "CODER_URL", srv.URL)
+	setFakeEnv(t, "CODER_TOKEN", "fake-token")
+
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "ca.pem")
+	err := ioutil.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600)
+	assert.Success(t, "write CA bundle", err)
+	invalid := filepath.Join(dir, "invalid.pem")
+	assert.Success(t, "write invalid bundle", ioutil.WriteFile(invalid, []byte("not a certificate"), 0600))
+
+	setFakeEnv(t, "CODER_CA_CERT", bundle)
+	_, err = newClient(context.Background())
+	assert.Success(t, "trust the CA from the environment", err)
+
+	caCert = invalid
+	defer func() { caCert = "" }()
+	_, err = newClient(context.Background())
+	assert.Error(t, "the flag overrides the environment", err)
+	assert.True(t, "invalid bundle error", strings.Contains(err.Error(), "invalid CA certificate"))
+
+	caCert = filepath.Join(dir, "missing.pem")
+	_, err = newClient(context.Background())
+	assert.Error(t, "missing bundle", err)
+}
@@ -30,19 +30,62 @@ func lookupUserOrgs(user *coder.User, orgs []coder.Organization) []coder.Organiz
 	return userOrgs
 }
 
-// getEnvs returns all environments for the user.
+// lookupUser gets a user by email, falling back to an ID lookup when the given identifier isn't an email.
+func lookupUser(ctx context.Context, client *coder.Client, emailOrID string) (*coder.User, error) {
+	if emailOrID == coder.Me || strings.Contains(emailOrID, "@") {
+		return client.UserByEmail(ctx, emailOrID)
+	}
+	return client.UserByID(ctx, emailOrID)
+}
+
+// lookupOrgMember returns the ID of the member of the user's organizations identified by username, email or ID.
+func lookupOrgMember(ctx context.Context, client *coder.Client, nameOrID string) (string, error) {
+	orgs, err := client.Organizations(ctx)
+	if err != nil {
+		return "", xerrors.Errorf("get orgs: %w", err)
+	}
+	var usernames []string
+	for _, org := range orgs {
+		for _, member := range org.Members {
+			if member.Username == nameOrID || member.Email == nameOrID || member.ID == nameOrID {
+				return member.ID, nil
+			}
+			usernames = append(usernames, member.Username)
+		}
+	}
+	lines := []string{"environment owners must be members of one of your organizations"}
+	if match, found := closestMatch(nameOrID, usernames); found {
+		lines = append(lines, clog.Hintf("did you mean %q?", match))
+	}
+	return "", clog.Error(fmt.Sprintf("user %q not found", nameOrID), lines...)
+}
+
+// getEnvs returns all environments for the user, identified by email or ID.
 func getEnvs(ctx context.Context, client *coder.Client, email string) ([]coder.Environment, error) {
-	user, err := client.UserByEmail(ctx, email)
+	envs, _, err := getOrgEnvs(ctx, client, email, "")
+	return envs, err
+}
+
+// getOrgEnvs returns the environments for the user, identified by email or ID, in the given organization,
+// identified by name or ID. When org is empty, the environments of all of the user's organizations are returned.
+// The organizations which were searched are returned alongside.
+func getOrgEnvs(ctx context.Context, client *coder.Client, email, org string) ([]coder.Environment, []coder.Organization, error) {
+	user, err := lookupUser(ctx, client, email)
 	if err != nil {
-		return nil, xerrors.Errorf("get user: %w", err)
+		return nil, nil, xerrors.Errorf("get user: %w", err)
 	}
 
 	orgs, err := client.Organizations(ctx)
 	if err != nil {
-		return nil, xerrors.Errorf("get orgs: %w", err)
+		return nil, nil, xerrors.Errorf("get orgs: %w", err)
 	}
 
 	orgs = lookupUserOrgs(user, orgs)
+	if org != "" {
+		if orgs, err = lookupOrg(org, orgs); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	// NOTE: We don't know in advance how many envs we have so we can't pre-alloc.
 	var allEnvs []coder.Environment
@@ -50,12 +93,27 @@ func getEnvs(ctx context.Context, client *coder.Client, email string) ([]coder.E
 	for _, org := range orgs {
 		envs, err := client.EnvironmentsByOrganization(ctx, user.ID, org.ID)
 		if err != nil {
-			return nil, xerrors.Errorf("get envs for %s: %w", org.Name, err)
+			return nil, nil, xerrors.Errorf("get envs for %s: %w", org.Name, err)
 		}
 
 		allEnvs = append(allEnvs, envs...)
 	}
-	return allEnvs, nil
+	return allEnvs, orgs, nil
+}
+
+// lookupOrg returns the organization identified by name or ID, as a single element slice.
+func lookupOrg(nameOrID string, orgs []coder.Organization) ([]coder.Organization, error) {
+	names := make([]string, 0, len(orgs))
+	for _, org := range orgs {
+		if org.ID == nameOrID || org.Name == nameOrID {
+			return []coder.Organization{org}, nil
+		}
+		names = append(names, org.Name)
+	}
+	return nil, clog.Fatal(
+		"failed to find organization",
+		fmt.Sprintf("organization %q not found in %q", nameOrID, names),
+	)
 }
 
 // findEnv returns a single environment by name (if it exists.).
@@ -65,21 +123,124 @@ func findEnv(ctx context.Context, client *coder.Client, envName, userEmail strin
 		return nil, xerrors.Errorf("get environments: %w", err)
 	}
 
-	// NOTE: We don't know in advance where we will find the env, so we can't pre-alloc.
-	var found []string
 	for _, env := range envs {
 		if env.Name == envName {
 			return &env, nil
 		}
-		// Keep track of what we found for the logs.
-		found = append(found, env.Name)
 	}
+	return nil, envNotFoundError(fmt.Sprintf("environment %q not found", envName), envName, envs)
+}
+
+// maxListedEnvs caps the number of environment names listed when an environment is not found.
+const maxListedEnvs = 10
 
+// envNotFoundError reports that no environment matches envName among envs with the given reason,
+// listing the first environment names and suggesting the closest one.
+func envNotFoundError(reason, envName string, envs []coder.Environment) error {
+	names := envNames(envs)
+	lines := []string{reason}
+	switch {
+	case len(names) == 0:
+		lines = append(lines, "you have no environments")
+	case len(names) > maxListedEnvs:
+		lines = append(lines, fmt.Sprintf("your environments are %q and %d more", names[:maxListedEnvs], len(names)-maxListedEnvs))
+	default:
+		lines = append(lines, fmt.Sprintf("your environments are %q", names))
+	}
+	if match, found := closestMatch(envName, names); found {
+		lines = append(lines, clog.Hintf("did you mean %q?", match))
+	}
+	lines = append(lines, clog.BlankLine, clog.Tipf("run \"coder envs ls\" to view your environments"))
+	return clog.Fatal("failed to find environment", lines...)
+}
+
+// findOrgEnv returns a single environment by name in the given organization, identified by name or ID.
+// When org is empty, all of the user's organizations are searched, and an environment name found in
+// several organizations is an error.
+func findOrgEnv(ctx context.Context, client *coder.Client, envName, userEmail, org string) (*coder.Environment, error) {
+	envs, orgs, err := getOrgEnvs(ctx, client, userEmail, org)
+	if err != nil {
+		return nil, xerrors.Errorf("get environments: %w", err)
+	}
+
+	var matches []int
+	for i := range envs {
+		if envs[i].Name == envName {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, envNotFoundError(fmt.Sprintf("environment %q not found", envName), envName, envs)
+	case 1:
+		return &envs[matches[0]], nil
+	}
+	return nil, ambiguousOrgEnvError(envName, envs, matches, orgs)
+}
+
+// ambiguousOrgEnvError lists the organizations of the environments at the given indices, which share the same name.
+func ambiguousOrgEnvError(envName string, envs []coder.Environment, matches []int, orgs []coder.Organization) error {
+	orgNames := make(map[string]string, len(orgs))
+	for _, org := range orgs {
+		orgNames[org.ID] = org.Name
+	}
+	matchingOrgs := make([]string, 0, len(matches))
+	for _, i := range matches {
+		matchingOrgs = append(matchingOrgs, orgNames[envs[i].OrganizationID])
+	}
+	return clog.Fatal(
+		fmt.Sprintf("environment name %q is ambiguous", envName),
+		fmt.Sprintf("it exists in the organizations %q", matchingOrgs),
+		clog.BlankLine,
+		clog.Tipf("use \"--org\" to select the organization"),
+	)
+}
+
+// findEnvByPrefix returns the environment whose name is or uniquely starts with the given prefix,
+// in the given organization like findOrgEnv.
+func findEnvByPrefix(ctx context.Context, client *coder.Client, prefix, userEmail, org string) (*coder.Environment, error) {
+	envs, orgs, err := getOrgEnvs(ctx, client, userEmail, org)
+	if err != nil {
+		return nil, xerrors.Errorf("get environments: %w", err)
+	}
+
+	var exact, matches []int
+	for i, name := range envNames(envs) {
+		if name == prefix {
+			exact = append(exact, i)
+		}
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, i)
+		}
+	}
+	switch len(exact) {
+	case 0:
+	case 1:
+		return &envs[exact[0]], nil
+	default:
+		return nil, ambiguousOrgEnvError(prefix, envs, exact, orgs)
+	}
+	switch len(matches) {
+	case 0:
+		return nil, envNotFoundError(fmt.Sprintf("no environment name starts with %q", prefix), prefix, envs)
+	case 1:
+		return &envs[matches[0]], nil
+	}
+	candidates := make([]string, 0, len(matches))
+	sameName := true
+	for _, i := range matches {
+		candidates = append(candidates, envs[i].Name)
+		sameName = sameName && envs[i].Name == candidates[0]
+	}
+	if sameName {
+		// All the matches share one name, which only the organization disambiguates.
+		return nil, ambiguousOrgEnvError(candidates[0], envs, matches, orgs)
+	}
 	return nil, clog.Fatal(
-		"failed to find environment",
-		fmt.Sprintf("environment %q not found in %q", envName, found),
+		fmt.Sprintf("environment name %q is ambiguous", prefix),
+		fmt.Sprintf("it matches %q", candidates),
 		clog.BlankLine,
-		clog.Tipf("run \"coder envs ls\" to view your environments"),
+		clog.Tipf("use the full name of the environment"),
 	)
 }
 
@@ -2,15 +2,122 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/coder-cli/pkg/clog"
 )
 
 // verbose is a global flag for specifying that a command should give verbose output.
 var verbose bool = false
 
+// quiet is a global flag for specifying that only warnings and errors should be logged.
+var quiet bool = false
+
+// noColor is a global flag for specifying that the output should not be colored.
+var noColor bool = false
+
+// tokenFile is a global flag for specifying a file to read the session token from.
+var tokenFile string
+
+// logFormat is a global flag for specifying the format of the logs written to stderr.
+var logFormat = clog.FormatHuman
+
+// proxy is a global flag for specifying the proxy of the requests sent to Coder, in place of the
+// one set by the environment.
+var proxy string
+
+// insecure is a global flag for specifying that the TLS certificate of Coder should not be verified.
+var insecure bool
+
+// caCert is a global flag for specifying a PEM file of certificate authorities to trust, in addition
+// to the system ones, when verifying the TLS certificate of Coder.
+var caCert string
+
+// commandTimeout is a global flag for specifying the maximum duration of the whole command,
+// including the authentication, which is unbounded when zero.
+var commandTimeout time.Duration
+
+// commandDeadline cancels the context of the command run by ExecuteContext once commandTimeout elapses.
+var commandDeadline struct {
+	sync.Mutex
+	cancel   context.CancelFunc
+	timer    *time.Timer
+	timedOut bool
+}
+
+// ExecuteContext executes app with ctx, which is canceled once the --command-timeout elapses,
+// so that a hung request aborts the command with a clear error.
+func ExecuteContext(ctx context.Context, app *cobra.Command) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	commandDeadline.Lock()
+	commandDeadline.cancel, commandDeadline.timer, commandDeadline.timedOut = cancel, nil, false
+	commandDeadline.Unlock()
+
+	err := app.ExecuteContext(ctx)
+
+	commandDeadline.Lock()
+	defer commandDeadline.Unlock()
+	if commandDeadline.timer != nil {
+		commandDeadline.timer.Stop()
+	}
+	commandDeadline.cancel = nil
+	if err != nil && commandDeadline.timedOut {
+		// The errors of the canceled requests don't tell why they were canceled.
+		return clog.Error(
+			fmt.Sprintf("operation timed out after %s", commandTimeout), clog.BlankLine,
+			clog.Tipf(`use "--command-timeout" to allow more time`),
+		)
+	}
+	return err
+}
+
+// startCommandDeadline starts counting down the --command-timeout of the command run by ExecuteContext.
+func startCommandDeadline() {
+	commandDeadline.Lock()
+	defer commandDeadline.Unlock()
+	if commandTimeout <= 0 || commandDeadline.cancel == nil || commandDeadline.timer != nil {
+		return
+	}
+	cancel := commandDeadline.cancel
+	commandDeadline.timer = time.AfterFunc(commandTimeout, func() {
+		commandDeadline.Lock()
+		commandDeadline.timedOut = true
+		commandDeadline.Unlock()
+		cancel()
+	})
+}
+
+// applyGlobalFlags configures the shared packages according to the global flags.
+// Commands overriding PersistentPreRunE must call it themselves.
+func applyGlobalFlags() error {
+	if quiet && verbose {
+		return xerrors.New("--quiet and --verbose cannot be used together")
+	}
+	if commandTimeout < 0 {
+		return xerrors.Errorf("invalid --command-timeout %s", commandTimeout)
+	}
+	startCommandDeadline()
+	clog.SetQuiet(quiet)
+	if noColor {
+		clog.DisableColor()
+	}
+	if err := clog.SetFormat(logFormat); err != nil {
+		return err
+	}
+	warnOutputEnv()
+	return nil
+}
+
 // Make constructs the "coder" root command.
 func Make() *cobra.Command {
 	app := &cobra.Command{
@@ -19,6 +126,9 @@ func Make() *cobra.Command {
 		SilenceErrors:     true,
 		SilenceUsage:      true,
 		DisableAutoGenTag: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return applyGlobalFlags()
+		},
 	}
 
 	app.AddCommand(
@@ -38,7 +148,15 @@ func Make() *cobra.Command {
 		imgsCmd(),
 		genDocsCmd(app),
 	)
-	app.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "show verbose output")
+	app.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "show verbose output, including the HTTP requests sent to Coder")
+	app.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only log warnings and errors")
+	app.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output, also disabled when NO_COLOR is set")
+	app.PersistentFlags().StringVar(&tokenFile, "token-file", "", "read the session token from a file, defaults to $"+tokenFileEnv)
+	app.PersistentFlags().StringVar(&logFormat, "log-format", clog.FormatHuman, "format of the logs written to stderr (human|json)")
+	app.PersistentFlags().StringVar(&proxy, "proxy", "", "URL of the proxy of the requests sent to Coder, defaults to $HTTPS_PROXY or $HTTP_PROXY")
+	app.PersistentFlags().BoolVar(&insecure, "insecure", false, "skip the verification of the TLS certificate of Coder, for self-signed certificates")
+	app.PersistentFlags().StringVar(&caCert, "ca-cert", "", "PEM file of certificate authorities to trust for Coder's certificate, defaults to $"+caCertEnv)
+	app.PersistentFlags().DurationVar(&commandTimeout, "command-timeout", 0, "maximum duration of the whole command, including the authentication, unbounded by default")
 	return app
 }
 
@@ -40,7 +40,13 @@ func envsCmd() *cobra.Command {
 
 const (
 	humanOutput = "human"
+	wideOutput  = "wide"
 	jsonOutput  = "json"
+	yamlOutput  = "yaml"
+
+	jsonLinesOutput = "json-lines"
+	templateOutput  = "template"
+	csvOutput       = "csv"
 )
 
 func lsEnvsCommand(user *string) *cobra.Command {
@@ -85,7 +91,7 @@ func lsEnvsCommand(user *string) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputFmt, "output", "o", humanOutput, "human | json")
+	cmd.Flags().StringVarP(&outputFmt, "output", "o", defaultOutputFormat(humanOutput, jsonOutput), "human | json, defaults to $"+outputEnv)
 
 	return cmd
 }
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"cdr.dev/coder-cli/pkg/clog"
+)
+
+// redacted replaces the sensitive values of logged requests.
+const redacted = "REDACTED"
+
+// sensitiveHeaders are the request headers which may carry credentials.
+var sensitiveHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"Session-Token": true,
+}
+
+// loggingTransport logs every HTTP request sent to Coder, with its status and duration.
+// Credentials are scrubbed from the logged URLs and headers.
+type loggingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	lines := redactHeaders(req.Header)
+	if err != nil {
+		lines = append([]string{fmt.Sprintf("failed after %s", elapsed), clog.Causef(err.Error())}, lines...)
+	} else {
+		lines = append([]string{fmt.Sprintf("%s in %s", resp.Status, elapsed)}, lines...)
+	}
+	clog.LogInfo(fmt.Sprintf("%s %s", req.Method, redactURL(req.URL)), lines...)
+	return resp, err
+}
+
+// redactURL gives the string form of u without credentials in its user info or query.
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	if u.User != nil {
+		redactedURL.User = url.User(redacted)
+	}
+	query := u.Query()
+	for key := range query {
+		if strings.Contains(strings.ToLower(key), "token") {
+			query.Set(key, redacted)
+		}
+	}
+	redactedURL.RawQuery = query.Encode()
+	return redactedURL.String()
+}
+
+// redactHeaders formats the given headers as sorted "key: value" lines, with the sensitive values scrubbed.
+func redactHeaders(header http.Header) []string {
+	lines := make([]string, 0, len(header))
+	for key, values := range header {
+		value := strings.Join(values, ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+			value = redacted
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", key, value))
+	}
+	sort.Strings(lines)
+	return lines
+}
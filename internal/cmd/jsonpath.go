@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/coder-cli/pkg/clog"
+)
+
+// jsonPathStep is a step of a jsonPath, selecting a field of objects, an element of arrays,
+// or every element of arrays.
+type jsonPathStep struct {
+	field string
+	index int
+	all   bool
+}
+
+// jsonPath is a parsed --json-path expression. The supported subset of JSONPath is the "$" root,
+// followed by any number of ".field", "[index]" and "[*]" steps, e.g. "$[*].url" or "$[0].port".
+type jsonPath []jsonPathStep
+
+// jsonPathStepRx matches the next step of a jsonPath expression.
+var jsonPathStepRx = regexp.MustCompile(`^(?:\.([A-Za-z_][A-Za-z0-9_]*)|\[(\*|[0-9]+)\])`)
+
+// parseJSONPath parses the given expression, in the subset documented by jsonPath.
+func parseJSONPath(expr string) (jsonPath, error) {
+	invalid := func(cause string) error {
+		return clog.Error(
+			fmt.Sprintf("invalid --json-path %q", expr),
+			clog.Causef(cause), clog.BlankLine,
+			clog.Tipf(`expressions are made of "$" followed by ".field", "[index]" and "[*]" steps, like "$[*].url"`),
+		)
+	}
+	if len(expr) < 1 || expr[0] != '$' {
+		return nil, invalid(`expressions must start with "$"`)
+	}
+	var path jsonPath
+	for rest := expr[1:]; rest != ""; {
+		match := jsonPathStepRx.FindStringSubmatch(rest)
+		if match == nil {
+			return nil, invalid(fmt.Sprintf("unsupported step at %q", rest))
+		}
+		rest = rest[len(match[0]):]
+		switch {
+		case match[1] != "":
+			path = append(path, jsonPathStep{field: match[1]})
+		case match[2] == "*":
+			path = append(path, jsonPathStep{all: true})
+		default:
+			index, err := strconv.Atoi(match[2])
+			if err != nil {
+				return nil, invalid(err.Error())
+			}
+			path = append(path, jsonPathStep{index: index})
+		}
+	}
+	return path, nil
+}
+
+// eval returns the values matched by the path in the given decoded json document. Missing
+// fields and out of range indexes match nothing.
+func (p jsonPath) eval(doc interface{}) []interface{} {
+	values := []interface{}{doc}
+	for _, step := range p {
+		var next []interface{}
+		for _, value := range values {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if field, ok := v[step.field]; ok && step.field != "" {
+					next = append(next, field)
+				}
+			case []interface{}:
+				switch {
+				case step.all:
+					next = append(next, v...)
+				case step.field == "" && step.index < len(v):
+					next = append(next, v[step.index])
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// writeJSONPath writes the values matched by path in the json encoding of list to out, one per line.
+// Strings are written as is, and other values as json.
+func writeJSONPath(out io.Writer, path jsonPath, list interface{}) error {
+	raw, err := json.Marshal(list)
+	if err != nil {
+		return xerrors.Errorf("encode as json: %w", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return xerrors.Errorf("decode json: %w", err)
+	}
+
+	w := bufio.NewWriter(out)
+	for _, value := range path.eval(doc) {
+		if s, ok := value.(string); ok {
+			fmt.Fprintln(w, s)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return xerrors.Errorf("encode as json: %w", err)
+		}
+		fmt.Fprintln(w, string(encoded))
+	}
+	return w.Flush()
+}
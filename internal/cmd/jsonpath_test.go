@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"cdr.dev/slog/sloggers/slogtest/assert"
+
+	"cdr.dev/coder-cli/coder-sdk"
+)
+
+func TestWriteJSONPath(t *testing.T) {
+	t.Parallel()
+
+	urls := []coder.DevURL{
+		{ID: "web-id", URL: "web.coder.com", Port: 8080, Name: "web"},
+		{ID: "api-id", URL: "api.coder.com", Port: 3000, Wildcard: true},
+	}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "$[*].url", want: "web.coder.com\napi.coder.com\n"},
+		{expr: "$[1].port", want: "3000\n"},
+		{expr: "$[*].wildcard", want: "true\n"},
+		{expr: "$[5].port", want: ""},
+		{expr: "$.url", want: ""},
+		{expr: "$[0].name", want: "web\n"},
+		// Objects are written as json, with sorted keys.
+		{expr: "$[1]", want: `{"access":"","id":"api-id","name":"","port":3000,"scheme":"","url":"api.coder.com","wildcard":true}` + "\n"},
+	}
+	for _, test := range tests {
+		path, err := parseJSONPath(test.expr)
+		assert.Success(t, "parse "+test.expr, err)
+
+		var out bytes.Buffer
+		assert.Success(t, "write "+test.expr, writeJSONPath(&out, path, urls))
+		assert.Equal(t, "values of "+test.expr, test.want, out.String())
+	}
+
+	for _, expr := range []string{"", "[*].url", "$..url", "$[-1]", "$.url[", "$['url']"} {
+		_, err := parseJSONPath(expr)
+		assert.Error(t, "parse "+expr, err)
+	}
+}
@@ -22,7 +22,14 @@ func loginCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "login [Coder Enterprise URL eg. https://my.coder.domain/]",
 		Short: "Authenticate this client for future operations",
-		Args:  cobra.ExactArgs(1),
+		Long: `Authenticate this client for future operations.
+
+The CODER_URL and CODER_TOKEN environment variables take precedence over the credentials saved by login.
+Both must be set together, which allows running commands unattended, e.g. in CI.
+
+To keep the token out of the environment, read it from a file with --token-file or CODER_TOKEN_FILE instead,
+e.g. a mounted secret. The token file takes precedence over CODER_TOKEN.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Pull the URL from the args and do some sanity check.
 			rawURL := args[0]
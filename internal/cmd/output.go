@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v2"
+
+	"cdr.dev/coder-cli/pkg/clog"
+	"cdr.dev/coder-cli/pkg/tablewriter"
+)
+
+// Helpers for rendering command output.
+
+// outputEnv sets the default --output format of the list commands.
+const outputEnv = "CODER_OUTPUT"
+
+// listOutputFormats are the formats of the list commands which can be set by $CODER_OUTPUT.
+// Template output isn't one of them, as it also requires --template.
+var listOutputFormats = []string{humanOutput, wideOutput, jsonOutput, jsonLinesOutput, yamlOutput, csvOutput}
+
+// defaultOutputFormat returns the format set by $CODER_OUTPUT when it's one of the supported
+// formats of the command, and human output otherwise.
+func defaultOutputFormat(supported ...string) string {
+	configured := strings.ToLower(os.Getenv(outputEnv))
+	for _, format := range supported {
+		if format == configured {
+			return format
+		}
+	}
+	return humanOutput
+}
+
+// warnOutputEnv warns when $CODER_OUTPUT is set to an unknown format.
+func warnOutputEnv() {
+	configured := os.Getenv(outputEnv)
+	if configured == "" {
+		return
+	}
+	for _, format := range listOutputFormats {
+		if format == strings.ToLower(configured) {
+			return
+		}
+	}
+	clog.LogWarn(
+		fmt.Sprintf("invalid %s %q, defaulting to %s output", outputEnv, configured, humanOutput),
+		clog.Hintf("valid formats are %q", listOutputFormats),
+	)
+}
+
+// newJSONEncoder creates a json encoder writing to w, indenting its output when pretty is set.
+func newJSONEncoder(w io.Writer, pretty bool) *json.Encoder {
+	enc := json.NewEncoder(w)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc
+}
+
+// clearScreen moves the cursor of the terminal to its top left corner and clears it.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// watchOutput runs write every interval until ctx is done or the user interrupts it, in which case nil is returned.
+// When stdout is a terminal, the screen is cleared before each write, otherwise the outputs are appended.
+// Failures are logged without stopping.
+func watchOutput(ctx context.Context, interval time.Duration, write func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	redraw := terminal.IsTerminal(int(os.Stdout.Fd()))
+	for ix := 0; ; ix++ {
+		if redraw {
+			fmt.Print(clearScreen)
+			fmt.Printf("Every %s, updated at %s\n\n", interval, time.Now().Format(time.Kitchen))
+		} else if ix > 0 {
+			fmt.Println()
+		}
+		if err := write(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			clog.Log(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// jsonError is the machine-readable form of a command failure.
+type jsonError struct {
+	Error string `json:"error"`
+}
+
+// withJSONErrors makes cmd and its subcommands write their failures to stdout
+// as a jsonError when their --output is json or json-lines, on top of the usual logs.
+func withJSONErrors(cmd *cobra.Command) {
+	for _, c := range cmd.Commands() {
+		withJSONErrors(c)
+	}
+
+	writeErr := func(cmd *cobra.Command, err error) error {
+		if err == nil {
+			return nil
+		}
+		if f := cmd.Flags().Lookup("output"); f != nil && (f.Value.String() == jsonOutput || f.Value.String() == jsonLinesOutput) {
+			_ = json.NewEncoder(os.Stdout).Encode(jsonError{Error: err.Error()}) // Best effort.
+		}
+		return err
+	}
+	if args := cmd.Args; args != nil {
+		cmd.Args = func(cmd *cobra.Command, a []string) error { return writeErr(cmd, args(cmd, a)) }
+	}
+	if run := cmd.RunE; run != nil {
+		cmd.RunE = func(cmd *cobra.Command, a []string) error { return writeErr(cmd, run(cmd, a)) }
+	}
+}
+
+// parseOutputTemplate parses the --template of the template output format.
+func parseOutputTemplate(outputFmt, text string) (*template.Template, error) {
+	if outputFmt != templateOutput {
+		if text != "" {
+			return nil, xerrors.Errorf("--template requires --output %s", templateOutput)
+		}
+		return nil, nil
+	}
+	if text == "" {
+		return nil, xerrors.Errorf("--output %s requires a --template", templateOutput)
+	}
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		return nil, clog.Error(fmt.Sprintf("invalid --template %q", text), clog.Causef(err.Error()))
+	}
+	return tmpl, nil
+}
+
+// writeTemplate executes tmpl for each element of a list, writing the results to out line by line.
+func writeTemplate(out io.Writer, tmpl *template.Template, length int, each func(i int) interface{}) error {
+	w := bufio.NewWriter(out)
+	for i := 0; i < length; i++ {
+		if err := tmpl.Execute(w, each(i)); err != nil {
+			return xerrors.Errorf("execute template: %w", err)
+		}
+		if _, err := w.WriteString("\n"); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// writeOutputFile writes data to the file at path, creating its parent directories.
+// An existing file is only overwritten with force.
+func writeOutputFile(path string, data []byte, force bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return xerrors.Errorf("create output file directory: %w", err)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if os.IsExist(err) {
+		return clog.Error(fmt.Sprintf("output file %q already exists", path), clog.Tipf("use --force to overwrite it"))
+	}
+	if err != nil {
+		return xerrors.Errorf("open output file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close() // Best effort.
+		return xerrors.Errorf("write output file: %w", err)
+	}
+	return f.Close()
+}
+
+// writeList writes list to out in the given output format.
+// For human, wide, csv and json-lines output, each gives the i-th element of the list.
+// Wide output shows the columns which are hidden from human output without truncating values,
+// json-lines output encodes every element as json on its own line,
+// and csv output has the columns of human output.
+func writeList(out io.Writer, outputFmt string, pretty bool, list interface{}, length int, each func(i int) interface{}, tableOpts ...tablewriter.Option) error {
+	tableOpts = append(tableOpts, tablewriter.Output(out))
+	switch outputFmt {
+	case humanOutput:
+		if err := tablewriter.WriteTable(length, each, tableOpts...); err != nil {
+			return xerrors.Errorf("write table: %w", err)
+		}
+	case wideOutput:
+		// Wide output shows full values, even if they don't fit in the terminal.
+		if err := tablewriter.WriteTable(length, each, append(tableOpts, tablewriter.ShowAllHidden(), tablewriter.Width(0))...); err != nil {
+			return xerrors.Errorf("write table: %w", err)
+		}
+	case jsonOutput:
+		if err := newJSONEncoder(out, pretty).Encode(list); err != nil {
+			return xerrors.Errorf("encode as json: %w", err)
+		}
+	case jsonLinesOutput:
+		enc := json.NewEncoder(out)
+		for i := 0; i < length; i++ {
+			if err := enc.Encode(each(i)); err != nil {
+				return xerrors.Errorf("encode as json: %w", err)
+			}
+		}
+	case csvOutput:
+		if err := tablewriter.WriteCSV(length, each, tableOpts...); err != nil {
+			return xerrors.Errorf("write csv: %w", err)
+		}
+	case yamlOutput:
+		if err := yaml.NewEncoder(out).Encode(list); err != nil {
+			return xerrors.Errorf("encode as yaml: %w", err)
+		}
+	default:
+		return xerrors.Errorf("unknown --output value %q, expected one of %q", outputFmt, listOutputFormats)
+	}
+	return nil
+}
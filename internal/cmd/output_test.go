@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"cdr.dev/slog/sloggers/slogtest/assert"
+	"golang.org/x/xerrors"
+)
+
+func TestWatchOutput(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		calls int
+		err   error
+	)
+	output := captureStdout(t, func() {
+		err = watchOutput(ctx, time.Millisecond, func(ctx context.Context) error {
+			calls++
+			if calls == 2 {
+				// Failures are logged without stopping.
+				return xerrors.New("transient failure")
+			}
+			if calls == 3 {
+				cancel()
+			}
+			fmt.Printf("call %d\n", calls)
+			return nil
+		})
+	})
+	assert.Success(t, "watch output", err)
+	assert.Equal(t, "calls", 3, calls)
+	// NOTE: Tests do not run in a terminal, so the outputs are appended.
+	assert.Equal(t, "appended output", "call 1\n\n\ncall 3\n", output)
+}
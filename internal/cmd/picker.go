@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/coder-cli/pkg/clog"
+)
+
+// Helpers for picking the environment of a command interactively.
+
+// withEnvPicker allows omitting the leading environment name of the arguments validated by
+// validate when stdin is a terminal, in which case pickEnvArg prompts for it.
+func withEnvPicker(validate cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		err := validate(cmd, args)
+		if err == nil || !canPickEnv() || !envNameOmitted(args) {
+			return err
+		}
+		if validate(cmd, append([]string{""}, args...)) == nil {
+			return nil
+		}
+		return err
+	}
+}
+
+// pickEnvArg prepends an interactively picked environment name to args when
+// they are not valid as is, as allowed by withEnvPicker.
+func pickEnvArg(cmd *cobra.Command, args []string, validate cobra.PositionalArgs) ([]string, error) {
+	if validate(cmd, args) == nil {
+		return args, nil
+	}
+	ctx := cmd.Context()
+	client, err := newClientWithTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	err = withAPITimeout(ctx, func(ctx context.Context) (err error) {
+		envs, _, err := getOrgEnvs(ctx, client, devURLUser, devURLOrg)
+		names = envNames(envs)
+		return err
+	})
+	if err != nil {
+		return nil, devURLUserError(err)
+	}
+	if len(names) < 1 {
+		return nil, clog.Error("no environments found", clog.Hintf(`create one with "coder envs create"`))
+	}
+
+	picker := promptui.Select{
+		Label:             "Select an environment",
+		Items:             names,
+		Searcher:          func(input string, i int) bool { return fuzzyMatch(input, names[i]) },
+		StartInSearchMode: true,
+	}
+	_, name, err := picker.Run()
+	if err != nil {
+		return nil, xerrors.Errorf("select environment: %w", err)
+	}
+	return append([]string{name}, args...), nil
+}
+
+// envNameOmitted reports whether args can't start with an environment name: there are none,
+// or the first one is a port, which environment names are assumed not to be. Otherwise, e.g.
+// with an environment name but no port, the arguments are invalid rather than missing a name.
+func envNameOmitted(args []string) bool {
+	if len(args) == 0 || args[0] == "auto" {
+		return true
+	}
+	_, err := strconv.ParseUint(args[0], 10, 16)
+	return err == nil
+}
+
+// canPickEnv reports whether the user can be prompted for an environment,
+// which is never the case when a default environment is set.
+func canPickEnv() bool {
+	return defaultEnvName() == "" && terminal.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// fuzzyMatch reports whether the characters of input appear in order in s, ignoring case.
+func fuzzyMatch(input, s string) bool {
+	s = strings.ToLower(s)
+	for _, r := range strings.ToLower(input) {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+utf8.RuneLen(r):]
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"cdr.dev/slog/sloggers/slogtest/assert"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input, s string
+		want     bool
+	}{
+		{"", "my-env", true},
+		{"my-env", "my-env", true},
+		{"myenv", "my-env", true},
+		{"MENV", "my-env", true},
+		{"env-my", "my-env", false},
+		{"myenvs", "my-env", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.input+" matches "+test.s, test.want, fuzzyMatch(test.input, test.s))
+	}
+}
+
+func TestEnvNameOmitted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{"no args", nil, true},
+		{"port", []string{"8080"}, true},
+		{"auto port", []string{"auto"}, true},
+		{"port and name", []string{"8080", "web"}, true},
+		{"env given, port missing", []string{"my-env"}, false},
+		{"devurl name", []string{"frontend"}, false},
+		{"out of range port", []string{"70000"}, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.name, test.want, envNameOmitted(test.args))
+	}
+}
+
+func TestEnvPickerNonInteractive(t *testing.T) {
+	newFakeCemanager(t)
+
+	// NOTE: Tests do not run in a terminal, so the environment can't be picked.
+	err := runCmd(t, "urls", "ls")
+	assert.True(t, "missing environment name", err != nil && strings.Contains(err.Error(), "accepts 1 arg(s), received 0"))
+}
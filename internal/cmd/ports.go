@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cdr.dev/wsep"
+	"golang.org/x/xerrors"
+	"nhooyr.io/websocket"
+
+	"cdr.dev/coder-cli/coder-sdk"
+)
+
+// Helpers for inspecting the network state of an environment.
+
+// tcpListenState is the socket state of listening sockets in /proc/net/tcp.
+const tcpListenState = "0A"
+
+// socketTableCommand prints the TCP sockets of an environment, in the /proc/net/tcp format.
+var socketTableCommand = wsep.Command{
+	Command: "cat",
+	Args:    []string{"/proc/net/tcp", "/proc/net/tcp6"},
+}
+
+// socketOwnersCommand prints a "socket:[<inode>] <comm>" line for each socket opened by a process
+// of the environment, as far as the permissions allow reading their file descriptors.
+var socketOwnersCommand = wsep.Command{
+	Command: "sh",
+	Args: []string{"-c", `for p in /proc/[0-9]*; do
+	c=$(cat "$p/comm" 2>/dev/null) || continue
+	for f in "$p"/fd/*; do
+		l=$(readlink "$f" 2>/dev/null)
+		case "$l" in socket:*) echo "$l $c" ;; esac
+	done
+done`},
+}
+
+// commonDevPorts are the ports development servers listen on by default, suggested for new devURLs
+// even before the service is running.
+var commonDevPorts = []int{3000, 4200, 5000, 5173, 8000, 8080, 8443, 8888, 9000}
+
+// commMaxLen is the length at which the kernel truncates process names in /proc/<pid>/comm.
+const commMaxLen = 15
+
+// listeningPorts returns the sorted list of TCP ports listened on inside the given environment.
+func listeningPorts(ctx context.Context, client *coder.Client, envID string) ([]int, error) {
+	stdout, err := runEnvCommand(ctx, client, envID, socketTableCommand)
+	if err != nil {
+		return nil, xerrors.Errorf("read socket table: %w", err)
+	}
+	return parseListeningPorts(stdout), nil
+}
+
+// processListeningPorts returns the sorted list of TCP ports listened on by the processes named
+// name inside the given environment.
+func processListeningPorts(ctx context.Context, client *coder.Client, envID, name string) ([]int, error) {
+	table, err := runEnvCommand(ctx, client, envID, socketTableCommand)
+	if err != nil {
+		return nil, xerrors.Errorf("read socket table: %w", err)
+	}
+	owners, err := runEnvCommand(ctx, client, envID, socketOwnersCommand)
+	if err != nil {
+		return nil, xerrors.Errorf("read socket owners: %w", err)
+	}
+	return processPorts(parseListeningSockets(table), owners, name), nil
+}
+
+// runEnvCommand runs command inside the given environment and returns its stdout. Since the commands
+// read from /proc, where some files may be missing or unreadable, a non-zero exit is only an error when
+// nothing was written to stdout.
+func runEnvCommand(ctx context.Context, client *coder.Client, envID string, command wsep.Command) (*bytes.Buffer, error) {
+	conn, err := client.DialWsep(ctx, envID)
+	if err != nil {
+		return nil, xerrors.Errorf("dial websocket: %w", err)
+	}
+	defer func() { _ = conn.Close(websocket.StatusNormalClosure, "") }() // Best effort.
+
+	execer := wsep.RemoteExecer(conn)
+	process, err := execer.Start(ctx, command)
+	if err != nil {
+		return nil, xerrors.Errorf("exec remote process: %w", err)
+	}
+
+	var stdout bytes.Buffer
+	go func() { _, _ = io.Copy(ioutil.Discard, process.Stderr()) }() // Best effort.
+	if _, err := io.Copy(&stdout, process.Stdout()); err != nil {
+		return nil, xerrors.Errorf("read remote process output: %w", err)
+	}
+	// NOTE: cat exits non-zero when /proc/net/tcp6 is missing because ipv6 is disabled,
+	// in which case the ipv4 sockets were still written to stdout.
+	if err := process.Wait(); err != nil {
+		var exitErr wsep.ExitError
+		if !xerrors.As(err, &exitErr) || stdout.Len() == 0 {
+			return nil, err
+		}
+	}
+	return &stdout, nil
+}
+
+// parseListeningPorts extracts the sorted, unique listening ports from a /proc/net/tcp formatted socket table.
+func parseListeningPorts(r io.Reader) []int {
+	seen := map[int]bool{}
+	var ports []int
+	for _, port := range parseListeningSockets(r) {
+		if !seen[port] {
+			seen[port] = true
+			ports = append(ports, port)
+		}
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// parseListeningSockets maps the inodes of the listening sockets of a /proc/net/tcp formatted
+// socket table to their port.
+func parseListeningSockets(r io.Reader) map[string]int {
+	sockets := map[string]int{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// Each line looks like: "0: 00000000:1F90 00000000:0000 0A ... 1000 0 12345 1",
+		// where the tenth field is the inode.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != tcpListenState {
+			continue
+		}
+		sep := strings.LastIndex(fields[1], ":")
+		if sep < 0 {
+			continue
+		}
+		port, err := strconv.ParseUint(fields[1][sep+1:], 16, 16)
+		if err != nil {
+			continue
+		}
+		sockets[fields[9]] = int(port)
+	}
+	return sockets
+}
+
+// processPorts returns the sorted, unique ports of the listening sockets owned by the processes named
+// name, from the output of socketOwnersCommand.
+func processPorts(sockets map[string]int, owners io.Reader, name string) []int {
+	if len(name) > commMaxLen {
+		name = name[:commMaxLen]
+	}
+	seen := map[int]bool{}
+	var ports []int
+
+	scanner := bufio.NewScanner(owners)
+	for scanner.Scan() {
+		// Each line looks like: "socket:[12345] node".
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if len(fields) != 2 || fields[1] != name {
+			continue
+		}
+		inode := strings.TrimSuffix(strings.TrimPrefix(fields[0], "socket:["), "]")
+		port, found := sockets[inode]
+		if !found || seen[port] {
+			continue
+		}
+		seen[port] = true
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"cdr.dev/slog/sloggers/slogtest/assert"
+)
+
+func TestParseListeningPorts(t *testing.T) {
+	t.Parallel()
+
+	const table = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12345 1
+   1: 0100007F:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12346 1
+   2: 0100007F:1F90 0100007F:C350 01 00000000:00000000 00:00000000 00000000  1000        0 12347 1
+  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12348 1
+   1: 00000000000000000000000000000000:0BB8 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12349 1
+`
+	ports := parseListeningPorts(strings.NewReader(table))
+	assert.Equal(t, "listening ports", []int{22, 3000, 8080}, ports)
+}
+
+func TestProcessPorts(t *testing.T) {
+	t.Parallel()
+
+	const table = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12345 1
+   1: 00000000:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12346 1
+   2: 0100007F:1F90 0100007F:C350 01 00000000:00000000 00:00000000 00000000  1000        0 12347 1
+   3: 00000000:2382 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12348 1
+`
+	const owners = `socket:[12345] node
+socket:[12347] node
+socket:[12346] node
+socket:[12348] code-server-wor
+socket:[99999] node
+`
+	sockets := parseListeningSockets(strings.NewReader(table))
+	assert.Equal(t, "listening sockets", map[string]int{"12345": 8080, "12346": 3000, "12348": 9090}, sockets)
+
+	for _, scene := range []struct {
+		name string
+		want []int
+	}{
+		{"node", []int{3000, 8080}},
+		{"code-server-worker", []int{9090}},
+		{"python", nil},
+	} {
+		ports := processPorts(sockets, strings.NewReader(owners), scene.name)
+		assert.Equal(t, scene.name+" ports", scene.want, ports)
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect of the schemas generated by jsonSchemaOf.
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// jsonSchema is the subset of JSON Schema needed to describe the json output of the commands.
+type jsonSchema struct {
+	Schema     string                 `json:"$schema,omitempty"`
+	Title      string                 `json:"title,omitempty"`
+	Type       string                 `json:"type"`
+	Format     string                 `json:"format,omitempty"`
+	Items      *jsonSchema            `json:"items,omitempty"`
+	Properties map[string]*jsonSchema `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+}
+
+// jsonSchemaOf derives the schema of the json encoding of values of type t from its json struct tags.
+// Embedded structs are flattened the way encoding/json does, and fields without omitempty are required.
+func jsonSchemaOf(t reflect.Type) *jsonSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: jsonSchemaOf(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object"}
+	case reflect.Struct:
+		schema := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+		addJSONSchemaFields(schema, t)
+		return schema
+	default:
+		return &jsonSchema{Type: "object"}
+	}
+}
+
+// addJSONSchemaFields adds the exported fields of the struct type t to the properties of schema.
+func addJSONSchemaFields(schema *jsonSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addJSONSchemaFields(schema, field.Type)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = jsonSchemaOf(field.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -21,11 +21,15 @@ func secretsCmd() *cobra.Command {
 		Short:  "Interact with Coder Secrets",
 		Long:   "Interact with secrets objects owned by the active user.",
 		Hidden: true,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyGlobalFlags(); err != nil {
+				return err
+			}
 			clog.LogWarn(
 				"The 'secrets' command is now deprecated",
 				"It will be removed in the next minor release",
 			)
+			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 			_ = cmd.Help()
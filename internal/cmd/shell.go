@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -23,22 +24,59 @@ import (
 
 func getEnvsForCompletion(user string) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		ctx := cmd.Context()
+		ctx := completionContext(cmd)
 		client, err := newClient(ctx)
 		if err != nil {
 			return nil, cobra.ShellCompDirectiveDefault
 		}
-		envs, err := getEnvs(ctx, client, user)
+		names, err := getEnvNames(ctx, client, user)
 		if err != nil {
 			return nil, cobra.ShellCompDirectiveDefault
 		}
+		return names, cobra.ShellCompDirectiveDefault
+	}
+}
+
+// getEnvNames returns the names of the environments of the given user.
+func getEnvNames(ctx context.Context, client *coder.Client, user string) ([]string, error) {
+	envs, err := getEnvs(ctx, client, user)
+	if err != nil {
+		return nil, err
+	}
+	return envNames(envs), nil
+}
+
+// envNames returns the names of the given environments.
+func envNames(envs []coder.Environment) []string {
+	names := make([]string, 0, len(envs))
+	for _, e := range envs {
+		names = append(names, e.Name)
+	}
+	return names
+}
 
-		envNames := make([]string, 0, len(envs))
-		for _, e := range envs {
-			envNames = append(envNames, e.Name)
+// getEnumForCompletion completes a flag with the keys of choices, sorted, using their values as help text.
+func getEnumForCompletion(choices map[string]string) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		values := make([]string, 0, len(choices))
+		for value, help := range choices {
+			if help != "" {
+				value += "\t" + help
+			}
+			values = append(values, value)
 		}
-		return envNames, cobra.ShellCompDirectiveDefault
+		sort.Strings(values)
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completionContext returns the context of the given command.
+// Cobra does not forward the execution context to completion functions, so fall back to a background one.
+func completionContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
 	}
+	return context.Background()
 }
 
 func shCmd() *cobra.Command {
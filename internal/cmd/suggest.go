@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"strings"
+)
+
+// closestMatch returns the candidate nearest to s by edit distance, ignoring case.
+// Candidates requiring more edits than half their length are not considered similar enough.
+func closestMatch(s string, candidates []string) (string, bool) {
+	var (
+		best     string
+		bestDist = -1
+	)
+	s = strings.ToLower(s)
+	for _, c := range candidates {
+		dist := editDistance(s, strings.ToLower(c))
+		if dist > len(c)/2 {
+			continue
+		}
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = c, dist
+		}
+	}
+	return best, bestDist >= 0
+}
+
+// editDistance computes the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	// prev holds the distances between the previous prefix of a and every prefix of b.
+	prev := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur := make([]int, len(br)+1)
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(br)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
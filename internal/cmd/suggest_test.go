@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+
+	"cdr.dev/slog/sloggers/slogtest/assert"
+)
+
+func TestClosestMatch(t *testing.T) {
+	t.Parallel()
+
+	levels := []string{"authed", "org", "private", "public"}
+	tests := []struct {
+		input string
+		want  string
+		found bool
+	}{
+		{input: "publik", want: "public", found: true},
+		{input: "PUBLC", want: "public", found: true},
+		{input: "privat", want: "private", found: true},
+		{input: "orgg", want: "org", found: true},
+		{input: "auth", want: "authed", found: true},
+		{input: "everyone", found: false},
+		{input: "", found: false},
+	}
+	for _, test := range tests {
+		got, found := closestMatch(test.input, levels)
+		assert.Equal(t, "found match for "+test.input, test.found, found)
+		assert.Equal(t, "match for "+test.input, test.want, got)
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"cdr.dev/coder-cli/coder-sdk"
+	"cdr.dev/coder-cli/pkg/clog"
+)
+
+// devURLTimings reports the duration of the API calls made by the urls commands when set.
+var devURLTimings bool
+
+// apiTiming is the wall-clock duration of a step of a command.
+type apiTiming struct {
+	Step       string        `json:"step"`
+	Duration   time.Duration `json:"-"`
+	DurationMS int64         `json:"duration_ms"`
+}
+
+// apiTimings collects the timings of the running command, which may record them concurrently.
+var apiTimings struct {
+	sync.Mutex
+	steps []apiTiming
+	// written is the number of steps already included in the json output of the command.
+	written int
+}
+
+// timedDevURL is the json output of the commands printing a single devURL,
+// along with the timings of the command when --timings is set.
+type timedDevURL struct {
+	coder.DevURL
+	Timings []apiTiming `json:"timings,omitempty"`
+}
+
+// recordTiming records the duration of step since start, meant to be deferred as
+// defer recordTiming("step", time.Now()).
+func recordTiming(step string, start time.Time) {
+	elapsed := time.Since(start)
+	apiTimings.Lock()
+	defer apiTimings.Unlock()
+	apiTimings.steps = append(apiTimings.steps, apiTiming{Step: step, Duration: elapsed, DurationMS: elapsed.Milliseconds()})
+}
+
+// outputTimings returns the timings recorded so far for the json output of the command,
+// which are then not reported again. It's nil unless --timings is set.
+func outputTimings() []apiTiming {
+	if !devURLTimings {
+		return nil
+	}
+	apiTimings.Lock()
+	defer apiTimings.Unlock()
+	apiTimings.written = len(apiTimings.steps)
+	return append([]apiTiming{}, apiTimings.steps...)
+}
+
+// withTimings makes cmd and its subcommands report the recorded timings once they're done
+// when --timings is set, even if they fail. The commands printing a single devURL as json
+// include them in their output, see outputTimings. Other timings are logged, or written to
+// stderr as json when the --output is json or json-lines, so that stdout stays parsable.
+func withTimings(cmd *cobra.Command) {
+	for _, c := range cmd.Commands() {
+		withTimings(c)
+	}
+
+	run := cmd.RunE
+	if run == nil {
+		return
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		apiTimings.Lock()
+		apiTimings.steps, apiTimings.written = nil, 0
+		apiTimings.Unlock()
+
+		err := run(cmd, args)
+		if devURLTimings {
+			reportTimings(cmd)
+		}
+		return err
+	}
+}
+
+// reportTimings writes the recorded timings which weren't part of the json output,
+// in the format matching the --output of cmd.
+func reportTimings(cmd *cobra.Command) {
+	apiTimings.Lock()
+	defer apiTimings.Unlock()
+
+	steps := apiTimings.steps[apiTimings.written:]
+	if apiTimings.written > 0 && len(steps) == 0 {
+		return
+	}
+	if f := cmd.Flags().Lookup("output"); f != nil && (f.Value.String() == jsonOutput || f.Value.String() == jsonLinesOutput) {
+		_ = json.NewEncoder(os.Stderr).Encode(struct { // Best effort.
+			Timings []apiTiming `json:"timings"`
+		}{append([]apiTiming{}, steps...)})
+		return
+	}
+	lines := make([]string, 0, len(steps))
+	for _, timing := range steps {
+		lines = append(lines, fmt.Sprintf("%s: %s", timing.Step, timing.Duration.Round(time.Millisecond)))
+	}
+	clog.LogInfo("timings", lines...)
+}
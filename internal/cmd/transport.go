@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/coder-cli/pkg/clog"
+)
+
+// caCertEnv is the environment variable setting the default of the --ca-cert flag.
+const caCertEnv = "CODER_CA_CERT"
+
+// newTransport returns the transport of the requests sent to Coder, configured by the global flags.
+// Without them, the default transport is used, which honors $HTTP_PROXY, $HTTPS_PROXY and $NO_PROXY.
+func newTransport() (http.RoundTripper, error) {
+	caFile := caCert
+	if caFile == "" {
+		caFile = os.Getenv(caCertEnv)
+	}
+	if proxy == "" && !insecure && caFile == "" {
+		return http.DefaultTransport, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" {
+			return nil, clog.Error(
+				fmt.Sprintf("invalid --proxy %q", proxy),
+				clog.Hintf("the proxy must be a URL, like http://proxy.corp.com:3128"),
+			)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	transport.TLSClientConfig = &tls.Config{}
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	if insecure {
+		clog.LogWarn(
+			"skipping the verification of the TLS certificate of Coder",
+			"the connection may be intercepted, only use --insecure against a trusted deployment",
+			clog.BlankLine,
+			clog.Tipf(`use "--ca-cert" to trust the certificate authority of the deployment instead`),
+		)
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+	return transport, nil
+}
+
+// loadCertPool returns the system certificate pool, extended with the PEM certificates of the given file.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("read CA certificate: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		// The system pool isn't available on every platform, the given certificates are trusted anyway.
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(raw) {
+		return nil, clog.Error(
+			fmt.Sprintf("invalid CA certificate %q", path),
+			clog.Causef("no PEM encoded certificate found"),
+		)
+	}
+	return pool, nil
+}
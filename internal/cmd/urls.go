@@ -1,61 +1,160 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
+	"math/rand"
+	"net"
 	"net/http"
 	"os"
+	"path"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 
+	"github.com/manifoldco/promptui"
+	"github.com/pkg/browser"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
 	"golang.org/x/xerrors"
 
 	"cdr.dev/coder-cli/coder-sdk"
+	"cdr.dev/coder-cli/internal/config"
 	"cdr.dev/coder-cli/pkg/clog"
 	"cdr.dev/coder-cli/pkg/tablewriter"
 )
 
 func urlCmd() *cobra.Command {
-	var outputFmt string
+	var lsOpts listDevURLsOptions
 	cmd := &cobra.Command{
 		Use:   "urls",
 		Short: "Interact with environment DevURLs",
+		Long: `Interact with environment DevURLs.
+
+The environment name may be omitted when a default environment is set by the CODER_DEFAULT_ENV environment variable,
+or else by the default_env file of the coder configuration directory. Otherwise, when the environment name is omitted
+from ls, rm or create in an interactive terminal, it is picked from a list, as long as the first argument is a port if any.
+
+The environments of other members of your organizations are targeted with the owner/name form, where the owner
+is identified by username, email or ID, such as alice/web.
+
+New devurls are private unless the CODER_DEVURL_DEFAULT_ACCESS environment variable sets another default access level,
+and use the http scheme unless the CODER_DEVURL_DEFAULT_SCHEME environment variable sets another default scheme.`,
+		Example: `coder urls ls my-env
+coder urls create my-env 8080 --access public --yes
+coder urls rm my-env 8080`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyGlobalFlags(); err != nil {
+				return err
+			}
+			if access := defaultDevURLAccess(); !accessLevelIsValid(access) {
+				return xerrors.Errorf("invalid %s %q", devURLDefaultAccessEnv, os.Getenv(devURLDefaultAccessEnv))
+			}
+			warnDefaultDevURLScheme()
+			tokenExpiryCheck = true
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVar(&devURLUser, "user", coder.Me, "Specify the user, by email or ID, whose devurls to target")
+	cmd.PersistentFlags().StringVar(&devURLEnvID, "env-id", "", "ID of the environment, in place of the environment name argument")
+	cmd.PersistentFlags().StringVar(&devURLOrg, "org", "", "name or ID of the organization of the environment, defaults to all of your organizations")
+	cmd.PersistentFlags().BoolVar(&devURLExactEnv, "exact", true, "require the environment name to match exactly, --exact=false accepts a unique prefix")
+	cmd.PersistentFlags().DurationVar(&apiTimeout, "timeout", defaultAPITimeout, "maximum duration of each API request")
+	cmd.PersistentFlags().BoolVar(&devURLTimings, "timings", false, "report the duration of the API calls once the command is done, in the json output of create, rm, open and get")
+	cmd.PersistentFlags().IntVar(&apiRetries, "retries", defaultAPIRetries, "maximum number of retries of devurl requests failing with a network or server error")
+	lsArgs := func(cmd *cobra.Command, args []string) error {
+		if lsOpts.all {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
 	}
 	lsCmd := &cobra.Command{
-		Use:               "ls [environment_name]",
-		Short:             "List all DevURLs for an environment",
-		Args:              cobra.ExactArgs(1),
-		ValidArgsFunction: getEnvsForCompletion(coder.Me),
-		RunE:              listDevURLsCmd(&outputFmt),
+		Use:   "ls [environment_name]",
+		Short: "List all DevURLs for an environment",
+		Example: `coder urls ls my-env
+coder urls ls my-env --output json
+coder urls ls my-env --fields port,name,url
+coder urls ls --all --access public
+coder urls ls --all --older-than 720h
+coder urls ls my-env --output template --template '{{.Port}} {{.URL}}'
+coder urls ls my-env --json-path '$[*].url'`,
+		Args:              withEnvPicker(lsArgs),
+		ValidArgsFunction: getDevURLEnvsForCompletion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			args, err := pickEnvArg(cmd, args, lsArgs)
+			if err != nil {
+				return err
+			}
+			return listDevURLsCmd(&lsOpts)(cmd, args)
+		},
 	}
-	lsCmd.Flags().StringVarP(&outputFmt, "output", "o", humanOutput, "human|json")
-
-	rmCmd := &cobra.Command{
-		Use:   "rm [environment_name] [port]",
-		Args:  cobra.ExactArgs(2),
-		Short: "Remove a dev url",
-		RunE:  removeDevURL,
+	lsCmd.Flags().StringVarP(&lsOpts.outputFmt, "output", "o", defaultOutputFormat(listOutputFormats...), "human|wide|json|json-lines|yaml|csv|template, defaults to $"+outputEnv)
+	lsCmd.Flags().StringVar(&lsOpts.template, "template", "", "Go template executed for each DevURL with --output template, e.g. '{{.URL}}'")
+	lsCmd.Flags().BoolVar(&lsOpts.pretty, "pretty", false, "indent json output")
+	lsCmd.Flags().StringVar(&lsOpts.jsonPath, "json-path", "", `only print the values of the json output matched by a "$" rooted path of ".field", "[index]" and "[*]" steps, e.g. '$[*].url'`)
+	lsCmd.Flags().StringVar(&lsOpts.access, "access", "", "only show DevURLs with the given access level [private | org | authed | public]")
+	lsCmd.Flags().StringVar(&lsOpts.name, "name", "", "only show DevURLs with a name matching the given glob pattern")
+	lsCmd.Flags().BoolVar(&lsOpts.all, "all", false, "list the DevURLs of all of your environments")
+	lsCmd.Flags().BoolVar(&lsOpts.failOnEmpty, "fail-on-empty", false, "exit with an error when no DevURLs are found")
+	lsCmd.Flags().DurationVar(&lsOpts.olderThan, "older-than", 0, "only show DevURLs created more than the given duration ago, e.g. 720h")
+	lsCmd.Flags().StringVar(&lsOpts.sort, "sort", "port", "sort DevURLs by [port | name | access]")
+	lsCmd.Flags().BoolVarP(&lsOpts.watch, "watch", "w", false, "refresh the list every --interval until interrupted")
+	lsCmd.Flags().DurationVar(&lsOpts.interval, "interval", 2*time.Second, "delay between two refreshes with --watch")
+	lsCmd.Flags().BoolVar(&lsOpts.noHeaders, "no-headers", false, "omit the header row of human, wide and csv output")
+	lsCmd.Flags().StringVar(&lsOpts.fields, "fields", "", "comma separated columns of human, wide and csv output, in order, e.g. url,port,name")
+	lsCmd.Flags().StringVar(&lsOpts.outputFile, "output-file", "", "write the output to the given file instead of stdout")
+	lsCmd.Flags().BoolVar(&lsOpts.force, "force", false, "overwrite the --output-file if it already exists")
+	lsCmd.Flags().BoolVar(&lsOpts.count, "count", false, "only print the number of DevURLs")
+	lsCmd.Flags().BoolVar(&lsOpts.describe, "describe", false, "show a description of who can access each DevURL")
+	lsCmd.Flags().BoolVar(&lsOpts.reachable, "reachable", false, "check whether each DevURL responds, showing the status code of its response")
+	lsCmd.Flags().DurationVar(&lsOpts.reachableTimeout, "reachable-timeout", 5*time.Second, "maximum duration of each check of --reachable")
+	lsOpts.onlyAccess = map[string]*bool{}
+	for _, level := range coder.DevURLAccessLevels {
+		lsOpts.onlyAccess[level] = new(bool)
+		lsCmd.Flags().BoolVar(lsOpts.onlyAccess[level], onlyAccessFlag(level), false, "only show "+strings.ToLower(level)+" DevURLs, like --access "+strings.ToLower(level))
 	}
+	_ = lsCmd.RegisterFlagCompletionFunc("access", getAccessLevelsForCompletion())
 
 	cmd.AddCommand(
 		lsCmd,
-		rmCmd,
+		removeDevURLCmd(),
 		createDevURLCmd(),
+		openDevURLCmd(),
+		getDevURLCmd(),
+		renameDevURLCmd(),
+		applyDevURLsCmd(),
+		exportDevURLsCmd(),
+		importDevURLsCmd(),
+		validateDevURLsCmd(),
+		diffDevURLsCmd(),
+		summarizeDevURLsCmd(),
+		schemaDevURLsCmd(),
 	)
+	for _, c := range cmd.Commands() {
+		withDefaultEnvArg(c)
+		withEnvIDArg(c)
+	}
+	withJSONErrors(cmd)
+	withTimings(cmd)
 
 	return cmd
 }
 
-// DevURL is the parsed json response record for a devURL from cemanager.
-type DevURL struct {
-	ID     string `json:"id"     table:"-"`
-	URL    string `json:"url"    table:"URL"`
-	Port   int    `json:"port"   table:"Port"`
-	Name   string `json:"name"   table:"-"`
-	Access string `json:"access" table:"Access"`
+// devURLRecord is a DevURL annotated with the optional details shown by urls ls.
+type devURLRecord struct {
+	Environment  string `json:"environment,omitempty" yaml:"environment,omitempty" table:"-"`
+	coder.DevURL `yaml:",inline"`
+	Description  string `json:"description,omitempty" yaml:"description,omitempty" table:"-"`
+	// Reachable and Status are only set with --reachable, Status being unset when the devURL didn't respond.
+	Reachable *bool `json:"reachable,omitempty" yaml:"reachable,omitempty" table:"-"`
+	Status    *int  `json:"status,omitempty"    yaml:"status,omitempty"    table:"-"`
 }
 
 var urlAccessLevel = map[string]string{
@@ -66,6 +165,47 @@ var urlAccessLevel = map[string]string{
 	"PUBLIC":  "Anyone on the internet can access this link",
 }
 
+// devURLDefaultAccessEnv overrides the access level of new devURLs.
+const devURLDefaultAccessEnv = "CODER_DEVURL_DEFAULT_ACCESS"
+
+// defaultDevURLAccess returns the access level of new devURLs, in uppercase.
+func defaultDevURLAccess() string {
+	if access := os.Getenv(devURLDefaultAccessEnv); access != "" {
+		return strings.ToUpper(access)
+	}
+	return "PRIVATE"
+}
+
+// devURLDefaultSchemeEnv overrides the scheme of new devURLs.
+const devURLDefaultSchemeEnv = "CODER_DEVURL_DEFAULT_SCHEME"
+
+// defaultDevURLScheme returns the scheme of new devURLs, in lowercase. An invalid
+// $CODER_DEVURL_DEFAULT_SCHEME falls back to http, which warnDefaultDevURLScheme reports.
+func defaultDevURLScheme() string {
+	if scheme := strings.ToLower(os.Getenv(devURLDefaultSchemeEnv)); schemeIsValid(scheme) {
+		return scheme
+	}
+	return "http"
+}
+
+// warnDefaultDevURLScheme warns when $CODER_DEVURL_DEFAULT_SCHEME is set to an invalid scheme.
+func warnDefaultDevURLScheme() {
+	scheme := os.Getenv(devURLDefaultSchemeEnv)
+	if scheme == "" || schemeIsValid(strings.ToLower(scheme)) {
+		return
+	}
+	clog.LogWarn(
+		fmt.Sprintf("invalid %s %q, defaulting to http", devURLDefaultSchemeEnv, scheme),
+		clog.Hintf("valid schemes are %q", devURLSchemes),
+	)
+}
+
+// autoPortArg can be passed in place of a port to let the cemanager allocate any free port.
+const autoPortArg = "auto"
+
+// accessLevelsByExposure are the keys of urlAccessLevel, from the most to the least restricted.
+var accessLevelsByExposure = []string{"PRIVATE", "ORG", "AUTHED", "PUBLIC"}
+
 func validatePort(port string) (int, error) {
 	p, err := strconv.ParseUint(port, 10, 16)
 	if err != nil {
@@ -73,230 +213,2427 @@ func validatePort(port string) (int, error) {
 		return 0, err
 	}
 	if p < 1 {
-		// Port 0 means 'any free port', which we don't support.
+		// Any free port is requested with "auto" or --auto-port, which are handled before the port is validated.
 		return 0, xerrors.New("Port must be > 0")
 	}
 	return int(p), nil
 }
 
+// devURLSchemes are the protocols the cemanager may use to reach the
+// service behind a devURL.
+var devURLSchemes = []string{"http", "https"}
+
+func schemeIsValid(scheme string) bool {
+	for _, s := range devURLSchemes {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// getAccessLevelsForCompletion completes the access levels in lowercase, as accepted by the --access flags.
+func getAccessLevelsForCompletion() func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	choices := make(map[string]string, len(urlAccessLevel))
+	for level, description := range urlAccessLevel {
+		choices[strings.ToLower(level)] = description
+	}
+	return getEnumForCompletion(choices)
+}
+
+// getSchemesForCompletion completes the supported devURL schemes.
+func getSchemesForCompletion() func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	choices := make(map[string]string, len(devURLSchemes))
+	for _, scheme := range devURLSchemes {
+		choices[scheme] = ""
+	}
+	return getEnumForCompletion(choices)
+}
+
 func accessLevelIsValid(level string) bool {
 	_, ok := urlAccessLevel[level]
 	if !ok {
-		clog.Log(clog.Error("invalid access level"))
+		clog.Log(clog.Error("invalid access level", accessLevelHints(level)...))
 	}
 	return ok
 }
 
+// accessLevelError wraps an error of coder.ParseAccessLevel for the given level,
+// suggesting the closest valid one.
+func accessLevelError(err error, level string) error {
+	return clog.Error(err.Error(), accessLevelHints(level)...)
+}
+
+// accessLevelHints suggests the valid access level closest to the given invalid one, if any.
+func accessLevelHints(level string) []string {
+	levels := make([]string, 0, len(urlAccessLevel))
+	for l := range urlAccessLevel {
+		levels = append(levels, strings.ToLower(l))
+	}
+	sort.Strings(levels)
+
+	if match, found := closestMatch(strings.ToLower(level), levels); found {
+		return []string{clog.Hintf("did you mean %q?", match)}
+	}
+	return nil
+}
+
+type listDevURLsOptions struct {
+	outputFmt string
+	pretty    bool
+	access    string
+	name      string
+	all       bool
+	describe  bool
+	sort      string
+	template  string
+	count     bool
+	noHeaders bool
+	watch     bool
+	interval  time.Duration
+	// olderThan only keeps the DevURLs created before now minus its duration, when positive.
+	olderThan time.Duration
+	// undatedWarned is set once the server was found not to report creation times.
+	undatedWarned *bool
+	// outputFile receives the output instead of stdout when set, and is only overwritten with force.
+	outputFile string
+	force      bool
+	// out is where the output is written.
+	out io.Writer
+
+	// tmpl is the parsed template, when outputFmt is templateOutput.
+	tmpl        *template.Template
+	failOnEmpty bool
+
+	// onlyAccess holds the --only-<level> shorthands of access, by access level.
+	onlyAccess map[string]*bool
+	// jsonPath selects the values written from the json output when set, as parsed into path.
+	jsonPath string
+	path     jsonPath
+	// reachable checks whether each DevURL responds, waiting at most reachableTimeout for each.
+	reachable        bool
+	reachableTimeout time.Duration
+	// fields is the comma separated list of the columns of table and csv output, as parsed into columns.
+	fields  string
+	columns []string
+}
+
 // Run gets the list of active devURLs from the cemanager for the
 // specified environment and outputs info to stdout.
-func listDevURLsCmd(outputFmt *string) func(cmd *cobra.Command, args []string) error {
+func listDevURLsCmd(opts *listDevURLsOptions) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
-		client, err := newClient(ctx)
+
+		opts.access = strings.ToUpper(opts.access)
+		if opts.access != "" && !accessLevelIsValid(opts.access) {
+			return xerrors.Errorf("invalid access level %q", opts.access)
+		}
+		if err := opts.applyOnlyAccess(); err != nil {
+			return err
+		}
+		if _, ok := devURLSortKeys[opts.sort]; !ok {
+			return clog.Error(
+				fmt.Sprintf("invalid --sort value %q", opts.sort),
+				clog.Hintf("valid sort keys are %q", devURLSortKeyNames()),
+			)
+		}
+		if _, err := path.Match(opts.name, ""); err != nil {
+			return clog.Error(fmt.Sprintf("invalid --name pattern %q", opts.name), clog.Causef(err.Error()))
+		}
+		tmpl, err := parseOutputTemplate(opts.outputFmt, opts.template)
 		if err != nil {
 			return err
 		}
-		envName := args[0]
+		if opts.count && opts.outputFmt != humanOutput && opts.outputFmt != jsonOutput {
+			return xerrors.Errorf("--count only supports --output %s or %s", humanOutput, jsonOutput)
+		}
+		opts.tmpl = tmpl
+		if opts.jsonPath != "" {
+			if cmd.Flags().Changed("output") && opts.outputFmt != jsonOutput {
+				return xerrors.Errorf("--json-path only supports --output %s", jsonOutput)
+			}
+			if opts.count {
+				return xerrors.New("--json-path cannot be used with --count")
+			}
+			if opts.path, err = parseJSONPath(opts.jsonPath); err != nil {
+				return err
+			}
+			opts.outputFmt = jsonOutput
+		}
+		if opts.watch && opts.failOnEmpty {
+			return xerrors.New("--watch cannot be used with --fail-on-empty")
+		}
+		if opts.watch && opts.interval <= 0 {
+			return xerrors.Errorf("invalid --interval %s", opts.interval)
+		}
+		if opts.watch && opts.outputFile != "" {
+			return xerrors.New("--watch cannot be used with --output-file")
+		}
+		if opts.force && opts.outputFile == "" {
+			return xerrors.New("--force requires --output-file")
+		}
+		if opts.reachable && opts.reachableTimeout <= 0 {
+			return xerrors.Errorf("invalid --reachable-timeout %s", opts.reachableTimeout)
+		}
+		if opts.olderThan < 0 {
+			return xerrors.Errorf("invalid --older-than %s", opts.olderThan)
+		}
+		if opts.fields != "" {
+			if opts.count || (opts.outputFmt != humanOutput && opts.outputFmt != wideOutput && opts.outputFmt != csvOutput) {
+				return xerrors.Errorf("--fields only supports --output %s, %s or %s", humanOutput, wideOutput, csvOutput)
+			}
+			if opts.columns, err = parseDevURLFields(opts.fields); err != nil {
+				return err
+			}
+		}
+		opts.undatedWarned = new(bool)
 
-		devURLs, err := urlList(ctx, client, envName)
+		client, err := newClientWithTimeout(ctx)
 		if err != nil {
 			return err
 		}
 
-		switch *outputFmt {
-		case humanOutput:
-			if len(devURLs) < 1 {
-				clog.LogInfo(fmt.Sprintf("no devURLs found for environment %q", envName))
-				return nil
-			}
-			err := tablewriter.WriteTable(len(devURLs), func(i int) interface{} {
-				return devURLs[i]
+		if opts.watch {
+			opts.out = os.Stdout
+			return watchOutput(ctx, opts.interval, func(ctx context.Context) error {
+				return opts.list(ctx, client, args)
 			})
-			if err != nil {
-				return xerrors.Errorf("write table: %w", err)
-			}
-		case jsonOutput:
-			if err := json.NewEncoder(os.Stdout).Encode(devURLs); err != nil {
-				return xerrors.Errorf("encode DevURLs as json: %w", err)
-			}
-		default:
-			return xerrors.Errorf("unknown --output value %q", *outputFmt)
 		}
-		return nil
+		if opts.outputFile == "" {
+			opts.out = os.Stdout
+			return opts.list(ctx, client, args)
+		}
+
+		// Render the whole output first, so that the file isn't left half written when listing fails.
+		var buf bytes.Buffer
+		opts.out = &buf
+		if err := opts.list(ctx, client, args); err != nil {
+			return err
+		}
+		return writeOutputFile(opts.outputFile, buf.Bytes(), opts.force)
 	}
 }
 
-func createDevURLCmd() *cobra.Command {
-	var (
-		access  string
-		urlname string
-	)
-	cmd := &cobra.Command{
-		Use:     "create [env_name] [port] [--access <level>] [--name <name>]",
-		Short:   "Create a new devurl for an environment",
-		Aliases: []string{"edit"},
-		Args:    cobra.ExactArgs(2),
-		// Run creates or updates a devURL
-		RunE: func(cmd *cobra.Command, args []string) error {
-			var (
-				envName = args[0]
-				port    = args[1]
-				ctx     = cmd.Context()
-			)
+// list fetches and writes the devURLs of the environment given in args, or of every environment with --all.
+func (opts listDevURLsOptions) list(ctx context.Context, client *coder.Client, args []string) error {
+	if opts.all {
+		devURLs, err := allEnvsDevURLs(ctx, client, opts.filter)
+		if err != nil {
+			return err
+		}
+		if len(devURLs) < 1 && opts.humanReadable() {
+			return opts.noDevURLs("no devURLs found")
+		}
+		if opts.reachable {
+			checkDevURLsReachable(ctx, client, devURLs, opts.reachableTimeout)
+		}
+		return opts.write(devURLs)
+	}
 
-			portNum, err := validatePort(port)
-			if err != nil {
-				return err
-			}
+	envName := args[0]
+	devURLs, err := urlList(ctx, client, envName)
+	if err != nil {
+		return err
+	}
+	devURLs = opts.filter(devURLs)
+	if len(devURLs) < 1 && opts.humanReadable() {
+		return opts.noDevURLs(fmt.Sprintf("no devURLs found for environment %q", envName))
+	}
+	records := make([]devURLRecord, 0, len(devURLs))
+	for _, url := range devURLs {
+		record := devURLRecord{DevURL: url}
+		if opts.outputFmt == wideOutput || opts.hasColumn("Environment") {
+			// Fill the environment column as wide output shows every column.
+			record.Environment = envName
+		}
+		records = append(records, record)
+	}
+	if opts.reachable {
+		checkDevURLsReachable(ctx, client, records, opts.reachableTimeout)
+	}
+	return opts.write(records)
+}
 
-			access = strings.ToUpper(access)
-			if !accessLevelIsValid(access) {
-				return xerrors.Errorf("invalid access level %q", access)
-			}
+// maxReachableChecks bounds the number of devURLs checked concurrently by urls ls --reachable.
+const maxReachableChecks = 8
 
-			if urlname != "" && !devURLNameValidRx.MatchString(urlname) {
-				return xerrors.New("update devurl: name must be < 64 chars in length, begin with a letter and only contain letters or digits.")
-			}
-			client, err := newClient(ctx)
-			if err != nil {
-				return err
-			}
+// checkDevURLsReachable sets whether each record responds to a GET request within timeout, with a
+// non-5xx status code like for --wait, and the status code of its response.
+func checkDevURLsReachable(ctx context.Context, client *coder.Client, records []devURLRecord, timeout time.Duration) {
+	// Don't follow redirects, e.g. to the login page of private devurls.
+	httpClient := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
 
-			env, err := findEnv(ctx, client, envName, coder.Me)
-			if err != nil {
-				return err
-			}
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxReachableChecks)
+	for i := range records {
+		record := &records[i]
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer func() { <-slots; wg.Done() }()
 
-			urls, err := urlList(ctx, client, envName)
-			if err != nil {
-				return err
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			status, err := pollDevURL(ctx, httpClient, devURLAddress(client, record.URL))
+			reachable := err == nil && status < http.StatusInternalServerError
+			record.Reachable = &reachable
+			if err == nil {
+				record.Status = &status
 			}
+		}()
+	}
+	wg.Wait()
+}
 
-			urlID, found := devURLID(portNum, urls)
-			if found {
-				clog.LogInfo(fmt.Sprintf("updating devurl for port %v", port))
-				err := client.PutDevURL(ctx, env.ID, urlID, coder.PutDevURLReq{
-					Port:   portNum,
-					Name:   urlname,
-					Access: access,
-					EnvID:  env.ID,
-					Scheme: "http",
-				})
-				if err != nil {
-					return xerrors.Errorf("update DevURL: %w", err)
-				}
-			} else {
-				clog.LogInfo(fmt.Sprintf("Adding devurl for port %v", port))
-				err := client.CreateDevURL(ctx, env.ID, coder.CreateDevURLReq{
-					Port:   portNum,
-					Name:   urlname,
-					Access: access,
-					EnvID:  env.ID,
-					Scheme: "http",
-				})
-				if err != nil {
-					return xerrors.Errorf("insert DevURL: %w", err)
-				}
+// write outputs the given devURLs in the requested format.
+func (opts listDevURLsOptions) write(records []devURLRecord) error {
+	less := devURLSortKeys[opts.sort]
+	sort.SliceStable(records, func(i, j int) bool {
+		// Keep the devURLs of an environment together.
+		if records[i].Environment != records[j].Environment {
+			return records[i].Environment < records[j].Environment
+		}
+		return less(records[i].DevURL, records[j].DevURL)
+	})
+	if opts.describe || opts.outputFmt == wideOutput || opts.hasColumn("Description") {
+		for i := range records {
+			records[i].Description = urlAccessLevel[strings.ToUpper(records[i].Access)]
+		}
+	}
+	each := func(i int) interface{} { return records[i] }
+	if opts.humanReadable() {
+		each = func(i int) interface{} {
+			record := records[i]
+			if record.Wildcard {
+				record.URL = wildcardMarker + record.URL
 			}
-			return nil
-		},
+			return record
+		}
+	}
+	var err error
+	if opts.count {
+		if opts.outputFmt == jsonOutput {
+			err = newJSONEncoder(opts.out, opts.pretty).Encode(devURLCount{Count: len(records)})
+		} else {
+			_, err = fmt.Fprintln(opts.out, len(records))
+		}
+	} else if opts.path != nil {
+		err = writeJSONPath(opts.out, opts.path, records)
+	} else if opts.outputFmt == templateOutput {
+		err = writeTemplate(opts.out, opts.tmpl, len(records), each)
+	} else {
+		err = writeList(opts.out, opts.outputFmt, opts.pretty, records, len(records), each, opts.tableOptions()...)
+	}
+	if err != nil {
+		return err
 	}
+	if len(records) < 1 && opts.failOnEmpty {
+		return clog.Error("no devURLs found")
+	}
+	return nil
+}
 
-	cmd.Flags().StringVar(&access, "access", "private", "Set DevURL access to [private | org | authed | public]")
-	cmd.Flags().StringVar(&urlname, "name", "", "DevURL name")
-	_ = cmd.MarkFlagRequired("name")
+// noDevURLs reports an empty human readable listing on stderr, so that stdout only carries
+// results. It's an error with --fail-on-empty.
+func (opts listDevURLsOptions) noDevURLs(msg string) error {
+	if opts.failOnEmpty {
+		return clog.Error(msg)
+	}
+	clog.LogInfo(msg)
+	return nil
+}
 
-	return cmd
+// devURLSortKeys are the orderings accepted by urls ls --sort. Ties are broken by port.
+var devURLSortKeys = map[string]func(a, b coder.DevURL) bool{
+	"port": func(a, b coder.DevURL) bool {
+		return a.Port < b.Port
+	},
+	"name": func(a, b coder.DevURL) bool {
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Port < b.Port
+	},
+	"access": func(a, b coder.DevURL) bool {
+		if a.Access != b.Access {
+			return a.Access < b.Access
+		}
+		return a.Port < b.Port
+	},
 }
 
-// devURLNameValidRx is the regex used to validate devurl names specified
-// via the --name subcommand. Named devurls must begin with a letter, and
-// consist solely of letters and digits, with a max length of 64 chars.
-var devURLNameValidRx = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9]{0,63}$")
+// devURLSortKeyNames returns the accepted --sort values in alphabetical order.
+func devURLSortKeyNames() []string {
+	keys := make([]string, 0, len(devURLSortKeys))
+	for k := range devURLSortKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
-// devURLID returns the ID of a devURL, given the env name and port
-// from a list of DevURL records.
-// ("", false) is returned if no match is found.
-func devURLID(port int, urls []DevURL) (string, bool) {
-	for _, url := range urls {
-		if url.Port == port {
-			return url.ID, true
+// wildcardMarker prefixes the URL of wildcard devURLs in human readable output.
+const wildcardMarker = "*."
+
+// humanReadable reports whether the devURLs are rendered as a table.
+func (opts listDevURLsOptions) humanReadable() bool {
+	return !opts.count && (opts.outputFmt == humanOutput || opts.outputFmt == wideOutput)
+}
+
+// devURLCount is the json output of urls ls --count.
+type devURLCount struct {
+	Count int `json:"count"`
+}
+
+// filter applies the filtering flags to the given devURLs.
+func (opts listDevURLsOptions) filter(urls []coder.DevURL) []coder.DevURL {
+	if opts.access != "" {
+		urls = filterDevURLsByAccess(urls, opts.access)
+	}
+	if opts.name != "" {
+		urls = filterDevURLsByName(urls, opts.name)
+	}
+	if opts.olderThan > 0 {
+		filtered, dated := filterDevURLsCreatedBefore(urls, time.Now().Add(-opts.olderThan))
+		if !dated {
+			if !*opts.undatedWarned {
+				clog.LogWarn(
+					"ignoring --older-than",
+					clog.Causef("the server doesn't report when devurls were created"),
+				)
+				*opts.undatedWarned = true
+			}
+			return urls
 		}
+		urls = filtered
 	}
-	return "", false
+	return urls
 }
 
-// Run deletes a devURL, specified by env ID and port, from the cemanager.
-func removeDevURL(cmd *cobra.Command, args []string) error {
-	var (
-		envName = args[0]
-		port    = args[1]
-		ctx     = cmd.Context()
-	)
+// onlyAccessFlag is the name of the --only-<level> shorthand of --access level.
+func onlyAccessFlag(level string) string {
+	return "only-" + strings.ToLower(level)
+}
 
-	portNum, err := validatePort(port)
-	if err != nil {
-		return xerrors.Errorf("validate port: %w", err)
+// applyOnlyAccess sets the access filter from the --only-<level> flags, which are mutually
+// exclusive with each other and with --access.
+func (opts *listDevURLsOptions) applyOnlyAccess() error {
+	var set []string
+	for _, level := range coder.DevURLAccessLevels {
+		if only := opts.onlyAccess[level]; only != nil && *only {
+			set = append(set, level)
+		}
 	}
+	switch {
+	case len(set) > 1:
+		return xerrors.Errorf("--%s cannot be used with --%s", onlyAccessFlag(set[0]), onlyAccessFlag(set[1]))
+	case len(set) == 1 && opts.access != "":
+		return xerrors.Errorf("--%s cannot be used with --access", onlyAccessFlag(set[0]))
+	case len(set) == 1:
+		opts.access = set[0]
+	}
+	return nil
+}
 
-	client, err := newClient(ctx)
-	if err != nil {
-		return err
+// tableOptions gives the table layout for the active flags.
+func (opts listDevURLsOptions) tableOptions() []tablewriter.Option {
+	var shown []string
+	if opts.all {
+		shown = append(shown, "Environment")
 	}
-	env, err := findEnv(ctx, client, envName, coder.Me)
-	if err != nil {
-		return err
+	if opts.name != "" {
+		// Show what the pattern matched.
+		shown = append(shown, "Name")
 	}
-
-	urls, err := urlList(ctx, client, envName)
-	if err != nil {
-		return err
+	if opts.describe {
+		shown = append(shown, "Description")
 	}
-
-	urlID, found := devURLID(portNum, urls)
-	if found {
-		clog.LogInfo(fmt.Sprintf("deleting devurl for port %v", port))
-	} else {
-		return xerrors.Errorf("No devurl found for port %v", port)
+	if opts.reachable {
+		shown = append(shown, "Reachable", "Status")
+	}
+	tableOpts := []tablewriter.Option{tablewriter.ShowHidden(shown...)}
+	if opts.noHeaders {
+		tableOpts = append(tableOpts, tablewriter.NoHeaders())
 	}
+	if opts.columns != nil {
+		tableOpts = append(tableOpts, tablewriter.Fields(opts.columns...))
+	}
+	return tableOpts
+}
 
-	if err := client.DeleteDevURL(ctx, env.ID, urlID); err != nil {
-		return xerrors.Errorf("delete DevURL: %w", err)
+// hasColumn reports whether the given column was selected with --fields.
+func (opts listDevURLsOptions) hasColumn(header string) bool {
+	return containsFold(opts.columns, header)
+}
+
+// parseDevURLFields parses the comma separated --fields of urls ls into the headers of the columns
+// of devURLRecord, which may be given in any case.
+func parseDevURLFields(fields string) ([]string, error) {
+	headers := tablewriter.FieldNames(devURLRecord{})
+	var columns []string
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		found := false
+		for _, header := range headers {
+			if strings.EqualFold(header, field) {
+				columns, found = append(columns, header), true
+				break
+			}
+		}
+		if !found {
+			valid := make([]string, 0, len(headers))
+			for _, header := range headers {
+				valid = append(valid, strings.ToLower(header))
+			}
+			return nil, clog.Error(
+				fmt.Sprintf("unknown --fields value %q", field),
+				clog.Hintf("valid fields are %q", valid),
+			)
+		}
 	}
-	return nil
+	return columns, nil
 }
 
-// urlList returns the list of active devURLs from the cemanager.
-func urlList(ctx context.Context, client *coder.Client, envName string) ([]DevURL, error) {
-	env, err := findEnv(ctx, client, envName, coder.Me)
+// allEnvsDevURLs gets the filtered devURLs of all environments of the authenticated user.
+// Environments whose devURLs cannot be listed are skipped with a warning.
+func allEnvsDevURLs(ctx context.Context, client *coder.Client, filter func([]coder.DevURL) []coder.DevURL) ([]devURLRecord, error) {
+	var envs []coder.Environment
+	err := withAPITimeout(ctx, func(ctx context.Context) (err error) {
+		envs, _, err = getOrgEnvs(ctx, client, devURLUser, devURLOrg)
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return nil, devURLUserError(err)
 	}
 
-	reqString := "%s/api/environments/%s/devurls?session_token=%s"
-	reqURL := fmt.Sprintf(reqString, client.BaseURL, env.ID, client.Token)
+	// NOTE: We don't know in advance how many devURLs we have so we can't pre-alloc.
+	var devURLs []devURLRecord
+	for i := range envs {
+		env := &envs[i]
+		urls, err := urlListForEnv(ctx, client, env)
+		if err != nil {
+			clog.LogWarn(fmt.Sprintf("skipping environment %q", env.Name), clog.Causef(err.Error()))
+			continue
+		}
+		for _, url := range filter(urls) {
+			devURLs = append(devURLs, devURLRecord{Environment: env.Name, DevURL: url})
+		}
+	}
+	return devURLs, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
-	if err != nil {
-		return nil, err
+// filterDevURLsByName returns the DevURLs with a name matching the given glob pattern.
+// The pattern is expected to be valid.
+func filterDevURLsByName(urls []coder.DevURL, pattern string) []coder.DevURL {
+	filtered := make([]coder.DevURL, 0, len(urls))
+	for _, url := range urls {
+		if ok, _ := path.Match(pattern, url.Name); ok {
+			filtered = append(filtered, url)
+		}
 	}
+	return filtered
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+// filterDevURLsCreatedBefore returns the DevURLs created before the given time. It returns
+// false when some of them have no creation time, as it can't tell their age.
+func filterDevURLsCreatedBefore(urls []coder.DevURL, before time.Time) ([]coder.DevURL, bool) {
+	filtered := make([]coder.DevURL, 0, len(urls))
+	for _, url := range urls {
+		if url.CreatedAt == nil {
+			return nil, false
+		}
+		if url.CreatedAt.Before(before) {
+			filtered = append(filtered, url)
+		}
 	}
-	defer func() { _ = resp.Body.Close() }() // Best effort.
+	return filtered, true
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, xerrors.Errorf("non-success status code: %d", resp.StatusCode)
+// filterDevURLsByAccess returns the DevURLs with the given access level.
+func filterDevURLsByAccess(urls []coder.DevURL, access string) []coder.DevURL {
+	filtered := make([]coder.DevURL, 0, len(urls))
+	for _, url := range urls {
+		if strings.EqualFold(url.Access, access) {
+			filtered = append(filtered, url)
+		}
 	}
+	return filtered
+}
 
-	dec := json.NewDecoder(resp.Body)
+func createDevURLCmd() *cobra.Command {
+	var (
+		access      string
+		urlname     string
+		scheme      string
+		wait        bool
+		waitTimeout time.Duration
+		outputFmt   string
+		pretty      bool
+		checkPort   bool
+		strict      bool
+		autoPort    bool
 
-	var devURLs []DevURL
-	if err := dec.Decode(&devURLs); err != nil {
-		return nil, err
+		updateIfExists bool
+		dryRun         bool
+		yes            bool
+		portSpecs      []string
+		noWarn         bool
+		printID        bool
+
+		portFromProcess string
+		firstPort       bool
+		wildcard        bool
+
+		allowDuplicateName bool
+		auditLog           string
+	)
+	createArgs := func(cmd *cobra.Command, args []string) error {
+		if len(portSpecs) > 0 || portFromProcess != "" {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
 	}
+	cmd := &cobra.Command{
+		Use:   "create [env_name] [port|auto] [--access <level>] [--name <name>] [--scheme <scheme>]",
+		Short: "Create a new devurl for an environment",
+		Long: `Create a new devurl for an environment.
 
-	return devURLs, nil
+Named devurls are reachable at an address derived from their name, which stays stable across ports.
+Unnamed devurls are only identified by their port, and their address is derived from it.
+
+Several devurls can be created at once by repeating --port with port:access:name tuples,
+where the access level and name are optional.
+
+Wildcard devurls also route every subdomain of their hostname to the port, which is only
+supported for unnamed devurls.
+
+With --port-from-process, the port is the one listened on by the process of the environment
+with the given name. Processes are matched by their name as found in /proc/<pid>/comm.
+
+Making a devurl public, by creating it or by updating its access level, writes a json audit line
+with the environment, port, previous and new access levels to stderr, or appends it to --audit-log.`,
+		Example: `coder urls create my-env 8080 --name web --access org
+coder urls create my-env 3000 --access public --yes
+coder urls create my-env auto --name api --wait
+coder urls create my-env --port 8080:public:web --port 9090:private:admin
+coder urls create my-env --port-from-process node --name web`,
+		Aliases:           []string{"edit"},
+		Args:              withEnvPicker(createArgs),
+		ValidArgsFunction: getDevURLPortsForCompletion(true),
+		// Run creates or updates a devURL
+		RunE: func(cmd *cobra.Command, args []string) error {
+			args, err := pickEnvArg(cmd, args, createArgs)
+			if err != nil {
+				return err
+			}
+			if portFromProcess != "" && (len(portSpecs) > 0 || autoPort) {
+				return xerrors.New("--port-from-process cannot be used with --port or --auto-port")
+			}
+			if firstPort && portFromProcess == "" {
+				return xerrors.New("--first requires --port-from-process")
+			}
+			if len(portSpecs) > 0 {
+				if urlname != "" || autoPort || wait || checkPort || strict || printID || wildcard || outputFmt != humanOutput {
+					return xerrors.New("--port cannot be used with --name, --auto-port, --wait, --check-port, --strict, --print-id, --wildcard or --output")
+				}
+				return createDevURLsFromSpecs(cmd, args[0], portSpecs, createDevURLSpecsOptions{
+					access:         access,
+					scheme:         scheme,
+					updateIfExists: updateIfExists,
+					dryRun:         dryRun,
+					yes:            yes,
+					noWarn:         noWarn,
+
+					allowDuplicateName: allowDuplicateName,
+					audit:              &devURLAuditLog{path: auditLog},
+				})
+			}
+			var (
+				envName = args[0]
+				port    string
+				ctx     = cmd.Context()
+			)
+			if portFromProcess == "" {
+				port = args[1]
+			}
+
+			if outputFmt != humanOutput && outputFmt != jsonOutput {
+				return xerrors.Errorf("unknown --output value %q", outputFmt)
+			}
+			if printID && outputFmt != humanOutput {
+				return xerrors.New("--print-id cannot be used with --output")
+			}
+
+			auto := port == autoPortArg || (autoPort && port == "0")
+			var portNum int
+			if !auto {
+				if autoPort {
+					return xerrors.Errorf("--auto-port requires port %q or %q, got %q", autoPortArg, "0", port)
+				}
+				var err error
+				if portFromProcess == "" {
+					if portNum, err = validatePort(port); err != nil {
+						return err
+					}
+				}
+			} else if checkPort || strict {
+				return xerrors.New("--check-port and --strict cannot be used with an automatically allocated port")
+			}
+
+			parsedAccess, err := coder.ParseAccessLevel(access)
+			if err != nil {
+				return accessLevelError(err, access)
+			}
+			access = parsedAccess
+
+			scheme = strings.ToLower(scheme)
+			if !schemeIsValid(scheme) {
+				return clog.Error(
+					fmt.Sprintf("invalid scheme %q", scheme),
+					clog.Hintf("valid schemes are %q", devURLSchemes),
+				)
+			}
+
+			if urlname != "" && !devURLNameValidRx.MatchString(urlname) {
+				return xerrors.Errorf("update devurl: %s", devURLNameRequirements)
+			}
+			if !auto && !noWarn && portFromProcess == "" {
+				warnSchemePort(scheme, portNum)
+			}
+			client, err := newClientWithTimeout(ctx)
+			if err != nil {
+				return err
+			}
+
+			env, err := findEnvWithTimeout(ctx, client, envName)
+			if err != nil {
+				return err
+			}
+
+			if portFromProcess != "" {
+				if portNum, err = processPort(ctx, client, env, portFromProcess, firstPort); err != nil {
+					return err
+				}
+				port = strconv.Itoa(portNum)
+				if !noWarn {
+					warnSchemePort(scheme, portNum)
+				}
+			}
+
+			if !auto && (checkPort || strict) {
+				if err := checkPortListening(ctx, client, env, portNum, strict); err != nil {
+					return err
+				}
+			}
+
+			urls, err := urlListForEnv(ctx, client, env)
+			if err != nil {
+				return err
+			}
+
+			if urlname != "" && !allowDuplicateName {
+				// The port of an automatically allocated devurl is unknown yet, so any devurl with the name conflicts.
+				otherPort := portNum
+				if auto {
+					otherPort = 0
+				}
+				if err := checkDevURLNameUnique(envName, urlname, otherPort, urls); err != nil {
+					return err
+				}
+			}
+
+			existing, found := devURLByPort(portNum, urls)
+			if found && !auto && !updateIfExists {
+				return clog.Error(
+					fmt.Sprintf("a devurl already exists for port %v", port),
+					clog.Tipf("pass --update-if-exists to overwrite it, or remove it with \"coder urls rm %s %v\"", envName, port),
+				)
+			}
+
+			if found && !auto && !cmd.Flags().Changed("access") {
+				// Keep the access level of the devurl being updated rather than resetting it to the default.
+				access = strings.ToUpper(existing.Access)
+			}
+			if found && !auto && !cmd.Flags().Changed("wildcard") {
+				wildcard = existing.Wildcard
+			}
+			if wildcard && urlname != "" {
+				return clog.Error(
+					"wildcard devurls cannot be named",
+					clog.Causef("the subdomains of a wildcard devurl are derived from its port"),
+					clog.Tipf("remove --name, or pass --wildcard=false"),
+				)
+			}
+			req := coder.CreateDevURLReq{
+				Port:     portNum,
+				Name:     urlname,
+				Access:   access,
+				EnvID:    env.ID,
+				Scheme:   scheme,
+				AutoPort: auto,
+				Wildcard: wildcard,
+			}
+			if dryRun {
+				run := devURLDryRun{Method: http.MethodPost, EnvID: env.ID, Request: &req}
+				if found && !auto {
+					run.Method, run.DevURLID = http.MethodPut, existing.ID
+				}
+				return writeDryRuns(outputFmt, pretty, []devURLDryRun{run})
+			}
+			if access == "PUBLIC" && !yes && !(found && strings.EqualFold(existing.Access, "PUBLIC")) {
+				if err := confirmPublicDevURL(port); err != nil {
+					return err
+				}
+			}
+
+			if auto {
+				start := time.Now()
+				created, err := createDevURLAutoPortWithRetries(ctx, client, env.ID, req)
+				recordTiming("create devurl", start)
+				if err != nil {
+					return insertDevURLError(err, urlname)
+				}
+				portNum, port = created.Port, strconv.Itoa(created.Port)
+				clog.LogSuccess(fmt.Sprintf("created devurl on allocated port %v", port))
+			} else if found {
+				start := time.Now()
+				err := withAPIRetries(ctx, func(ctx context.Context) error {
+					return client.PutDevURL(ctx, env.ID, existing.ID, coder.PutDevURLReq(req))
+				})
+				recordTiming("update devurl", start)
+				if err != nil {
+					return xerrors.Errorf("update DevURL: %w", err)
+				}
+				clog.LogSuccess(fmt.Sprintf("updated existing devurl for port %v", port))
+			} else {
+				start := time.Now()
+				err := createDevURLWithRetries(ctx, client, env.ID, req)
+				recordTiming("create devurl", start)
+				if err != nil {
+					return insertDevURLError(err, urlname)
+				}
+				clog.LogSuccess(fmt.Sprintf("created devurl for port %v", port))
+			}
+			var oldAccess string
+			if found && !auto {
+				oldAccess = existing.Access
+			}
+			audit := &devURLAuditLog{path: auditLog}
+			if err := audit.recordAccess(env.Name, portNum, oldAccess, access); err != nil {
+				return err
+			}
+
+			urls, err = urlListForEnv(ctx, client, env)
+			if err != nil {
+				return err
+			}
+			devURL, found := devURLByPort(portNum, urls)
+			if !found {
+				return xerrors.Errorf("No devurl found for port %v", port)
+			}
+
+			if printID {
+				if devURL.ID == "" {
+					return xerrors.Errorf("no ID was returned for the devurl of port %v", port)
+				}
+				fmt.Println(devURL.ID)
+			} else if outputFmt == jsonOutput {
+				if err := newJSONEncoder(os.Stdout, pretty).Encode(timedDevURL{DevURL: *devURL, Timings: outputTimings()}); err != nil {
+					return xerrors.Errorf("encode DevURL as json: %w", err)
+				}
+			} else {
+				fmt.Println(devURLAddress(client, devURL.URL))
+			}
+
+			if wait {
+				return waitForDevURL(ctx, devURLAddress(client, devURL.URL), waitTimeout)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&access, "access", strings.ToLower(defaultDevURLAccess()), "Set DevURL access to [private | org | authed | public], updates keep the current access level by default, defaults to $"+devURLDefaultAccessEnv)
+	cmd.Flags().StringVar(&urlname, "name", "", "DevURL name, leave empty to create an unnamed devurl")
+	cmd.Flags().StringVar(&scheme, "scheme", defaultDevURLScheme(), "Server scheme (http|https), defaults to $"+devURLDefaultSchemeEnv)
+	_ = cmd.RegisterFlagCompletionFunc("access", getAccessLevelsForCompletion())
+	_ = cmd.RegisterFlagCompletionFunc("scheme", getSchemesForCompletion())
+	cmd.Flags().StringVarP(&outputFmt, "output", "o", humanOutput, "human|json")
+	cmd.Flags().BoolVar(&pretty, "pretty", false, "indent json output")
+	cmd.Flags().BoolVar(&wait, "wait", false, "wait for the devurl to respond before exiting")
+	cmd.Flags().DurationVar(&waitTimeout, "wait-timeout", time.Minute, "maximum time to wait for the devurl to respond")
+	cmd.Flags().BoolVar(&checkPort, "check-port", false, "warn if nothing is listening on the port inside the environment")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "create public devurls without prompting for confirmation")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the request which would be sent instead of creating or updating the devurl")
+	cmd.Flags().BoolVar(&updateIfExists, "update-if-exists", true, "update the devurl if the port already has one, instead of failing")
+	cmd.Flags().BoolVar(&autoPort, "auto-port", false, "allow port 0 to ask the cemanager for any free port, same as passing \"auto\"")
+	cmd.Flags().BoolVar(&strict, "strict", false, "abort instead of warning when the port check fails (implies --check-port)")
+	cmd.Flags().BoolVar(&noWarn, "no-warn", false, "do not warn about suspicious scheme and port combinations")
+	cmd.Flags().StringVar(&portFromProcess, "port-from-process", "", "use the port listened on by the process with the given name, instead of the port argument")
+	cmd.Flags().BoolVar(&firstPort, "first", false, "use the lowest port when the --port-from-process process listens on several")
+	cmd.Flags().BoolVar(&allowDuplicateName, "allow-duplicate-name", false, "allow naming the devurl like another devurl of the environment")
+	cmd.Flags().BoolVar(&wildcard, "wildcard", false, "also route every subdomain of the devurl hostname to the port, updates keep the current setting by default")
+	cmd.Flags().BoolVar(&printID, "print-id", false, "only print the ID of the created or updated devurl, for scripting")
+	cmd.Flags().StringArrayVar(&portSpecs, "port", nil, "create a devurl for a port:access:name tuple instead of the port argument, can be repeated")
+	cmd.Flags().StringVar(&auditLog, "audit-log", "", "append the json audit lines of devurls made public to this file instead of stderr")
+
+	return cmd
+}
+
+// createDevURLSpecsOptions are the create flags applying to every devURL given with --port.
+type createDevURLSpecsOptions struct {
+	access         string
+	scheme         string
+	updateIfExists bool
+	dryRun         bool
+	yes            bool
+	noWarn         bool
+
+	allowDuplicateName bool
+	audit              *devURLAuditLog
+}
+
+// createDevURLsFromSpecs creates or updates a devURL for each port:access:name tuple, continuing past
+// individual failures. Tuples without an access level take the one of --access, or keep the current one
+// of the devURL being updated.
+func createDevURLsFromSpecs(cmd *cobra.Command, envName string, specs []string, opts createDevURLSpecsOptions) error {
+	ctx := cmd.Context()
+
+	scheme := strings.ToLower(opts.scheme)
+	if !schemeIsValid(scheme) {
+		return clog.Error(
+			fmt.Sprintf("invalid scheme %q", scheme),
+			clog.Hintf("valid schemes are %q", devURLSchemes),
+		)
+	}
+	entries := make([]devURLManifestEntry, 0, len(specs))
+	ports := make(map[int]bool, len(specs))
+	for _, spec := range specs {
+		entry, err := parseDevURLPortSpec(spec)
+		if err != nil {
+			return err
+		}
+		if ports[entry.Port] {
+			return xerrors.Errorf("duplicate --port %d", entry.Port)
+		}
+		ports[entry.Port] = true
+		entry.Scheme = scheme
+		if !opts.noWarn {
+			warnSchemePort(scheme, entry.Port)
+		}
+		entries = append(entries, entry)
+	}
+
+	client, err := newClientWithTimeout(ctx)
+	if err != nil {
+		return err
+	}
+	env, err := findEnvWithTimeout(ctx, client, envName)
+	if err != nil {
+		return err
+	}
+	urls, err := urlListForEnv(ctx, client, env)
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		url, found := devURLByPort(entry.Port, urls)
+		if found {
+			// Tuples can't set wildcard routing, so keep the one of the devURL being updated.
+			entry.Wildcard = url.Wildcard
+		}
+		if entry.Access != "" {
+			continue
+		}
+		if found && !cmd.Flags().Changed("access") {
+			entry.Access = strings.ToUpper(url.Access)
+			continue
+		}
+		if entry.Access, err = coder.ParseAccessLevel(opts.access); err != nil {
+			return accessLevelError(err, opts.access)
+		}
+	}
+
+	if !opts.allowDuplicateName {
+		names := make(map[string]int, len(entries))
+		for _, entry := range entries {
+			if entry.Name == "" {
+				continue
+			}
+			if port, found := names[entry.Name]; found {
+				return clog.Error(
+					fmt.Sprintf("devurl name %q is given to both port %v and %v", entry.Name, port, entry.Port),
+					clog.Tipf("pass --allow-duplicate-name to reuse it"),
+				)
+			}
+			names[entry.Name] = entry.Port
+			if err := checkDevURLNameUnique(envName, entry.Name, entry.Port, urls); err != nil {
+				return err
+			}
+		}
+	}
+
+	changes, _ := planDevURLChanges(entries, urls, false)
+	if len(changes) < 1 {
+		clog.LogSuccess("devurls are up to date")
+		return nil
+	}
+	for _, change := range changes {
+		if change.Action == devURLUpdate && !opts.updateIfExists {
+			return clog.Error(
+				fmt.Sprintf("a devurl already exists for port %v", change.Entry.Port),
+				clog.Tipf("pass --update-if-exists to overwrite it, or remove it with \"coder urls rm %s %v\"", envName, change.Entry.Port),
+			)
+		}
+	}
+
+	if opts.dryRun {
+		runs := make([]devURLDryRun, 0, len(changes))
+		for _, change := range changes {
+			entry := change.Entry
+			run := devURLDryRun{Method: http.MethodPost, EnvID: env.ID, Request: &coder.CreateDevURLReq{
+				Port:     entry.Port,
+				Name:     entry.Name,
+				Access:   entry.Access,
+				EnvID:    env.ID,
+				Scheme:   entry.Scheme,
+				Wildcard: entry.Wildcard,
+			}}
+			if change.Action == devURLUpdate {
+				run.Method, run.DevURLID = http.MethodPut, change.DevURL.ID
+			}
+			runs = append(runs, run)
+		}
+		return writeDryRuns(humanOutput, false, runs)
+	}
+	if !opts.yes {
+		if err := confirmPublicDevURLChanges(changes); err != nil {
+			return err
+		}
+	}
+
+	egroup := clog.LoggedErrGroup()
+	for _, change := range changes {
+		change := change
+		egroup.Go(func() error {
+			return applyDevURLChange(ctx, client, env, change, opts.audit)
+		})
+	}
+	return egroup.Wait()
+}
+
+// checkDevURLNameUnique errors when a devURL on another port than the given one is already named name.
+func checkDevURLNameUnique(envName, name string, port int, urls []coder.DevURL) error {
+	for _, url := range urls {
+		if url.Name != name || url.Port == port {
+			continue
+		}
+		return clog.Error(
+			fmt.Sprintf("devurl name %q is already used by port %v", name, url.Port),
+			clog.Tipf("pass --allow-duplicate-name to reuse it, or rename the other devurl with \"coder urls rename %s %v <name>\"", envName, url.Port),
+		)
+	}
+	return nil
+}
+
+// parseDevURLPortSpec parses a port:access:name tuple given to --port, where the access level
+// and name are optional.
+func parseDevURLPortSpec(spec string) (devURLManifestEntry, error) {
+	fields := strings.Split(spec, ":")
+	if len(fields) > 3 {
+		return devURLManifestEntry{}, xerrors.Errorf("invalid --port %q: expected port:access:name", spec)
+	}
+	port, err := validatePort(fields[0])
+	if err != nil {
+		return devURLManifestEntry{}, xerrors.Errorf("invalid --port %q: %w", spec, err)
+	}
+	entry := devURLManifestEntry{Port: port}
+	if len(fields) > 1 && fields[1] != "" {
+		if entry.Access, err = coder.ParseAccessLevel(fields[1]); err != nil {
+			return devURLManifestEntry{}, xerrors.Errorf("invalid --port %q: %w", spec, accessLevelError(err, fields[1]))
+		}
+	}
+	if len(fields) > 2 {
+		entry.Name = fields[2]
+		if entry.Name != "" && !devURLNameValidRx.MatchString(entry.Name) {
+			return devURLManifestEntry{}, xerrors.Errorf("invalid --port %q: %s", spec, devURLNameRequirements)
+		}
+	}
+	return entry, nil
+}
+
+// conventionalPortSchemes maps the well-known ports to the scheme they conventionally serve.
+var conventionalPortSchemes = map[int]string{
+	80:   "http",
+	443:  "https",
+	8443: "https",
+}
+
+// warnSchemePort warns when the scheme differs from the one conventionally served on the port,
+// e.g. https on port 80, which usually is a copy-paste mistake.
+func warnSchemePort(scheme string, port int) {
+	conventional, ok := conventionalPortSchemes[port]
+	if !ok || conventional == scheme {
+		return
+	}
+	clog.LogWarn(
+		fmt.Sprintf("port %d usually serves %s, not %s", port, conventional, scheme),
+		"the devurl will fail to connect if the service does not speak "+scheme, clog.BlankLine,
+		clog.Tipf("use \"--scheme %s\", or \"--no-warn\" to silence this warning", conventional),
+	)
+}
+
+// confirmPublicDevURL prompts the user before exposing the service on the given port to the internet.
+// Without a terminal to prompt on, the devURL is never made public.
+func confirmPublicDevURL(port string) error {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return clog.Error(
+			"refusing to create a public devurl without confirmation",
+			urlAccessLevel["PUBLIC"], clog.BlankLine,
+			clog.Tipf(`use "--yes" to create it from a non-interactive session`),
+		)
+	}
+
+	clog.LogWarn("public devurl", urlAccessLevel["PUBLIC"])
+	confirm := promptui.Prompt{
+		Label:     fmt.Sprintf("Make the devurl for port %v public?", port),
+		IsConfirm: true,
+	}
+	if _, err := confirm.Run(); err != nil {
+		return clog.Fatal(
+			"failed to confirm public access", clog.BlankLine,
+			clog.Tipf(`use "--yes" to create public devurls without a confirmation prompt`),
+		)
+	}
+	return nil
+}
+
+// confirmPublicDevURLChanges prompts the user like confirmPublicDevURL for each change making a devURL public.
+func confirmPublicDevURLChanges(changes []devURLChange) error {
+	for _, change := range changes {
+		if change.Entry == nil || change.Entry.Access != "PUBLIC" {
+			continue
+		}
+		if change.DevURL != nil && strings.EqualFold(change.DevURL.Access, "PUBLIC") {
+			continue
+		}
+		if err := confirmPublicDevURL(strconv.Itoa(change.Entry.Port)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// devURLDryRun describes a mutating devURL request which is not sent because of --dry-run.
+type devURLDryRun struct {
+	Method   string                 `json:"method"`
+	EnvID    string                 `json:"environment_id"`
+	DevURLID string                 `json:"url_id,omitempty"`
+	Request  *coder.CreateDevURLReq `json:"request,omitempty"`
+}
+
+// String gives a stable, single line summary of the request.
+func (r devURLDryRun) String() string {
+	s := fmt.Sprintf("%s env_id=%s", r.Method, r.EnvID)
+	if r.DevURLID != "" {
+		s += " url_id=" + r.DevURLID
+	}
+	if req := r.Request; req != nil {
+		port := strconv.Itoa(req.Port)
+		if req.AutoPort {
+			port = autoPortArg
+		}
+		s += fmt.Sprintf(" port=%s access=%s name=%q scheme=%s", port, req.Access, req.Name, req.Scheme)
+	}
+	return s
+}
+
+// writeDryRuns prints the requests which would have been sent, one per line for human output.
+func writeDryRuns(outputFmt string, pretty bool, runs []devURLDryRun) error {
+	clog.LogInfo("dry run, no request was sent")
+	if outputFmt == jsonOutput {
+		enc := newJSONEncoder(os.Stdout, pretty)
+		for _, run := range runs {
+			if err := enc.Encode(run); err != nil {
+				return xerrors.Errorf("encode dry run as json: %w", err)
+			}
+		}
+		return nil
+	}
+	for _, run := range runs {
+		fmt.Println(run)
+	}
+	return nil
+}
+
+// insertDevURLError wraps an error creating a devURL, suggesting a name when the devURL was unnamed
+// since deployments may reject anonymous devURLs.
+func insertDevURLError(err error, urlname string) error {
+	if urlname != "" {
+		return xerrors.Errorf("insert DevURL: %w", err)
+	}
+	return clog.Error(
+		fmt.Sprintf("insert unnamed DevURL: %v", err),
+		clog.Tipf("your deployment may require devurls to be named, try again with --name"),
+	)
+}
+
+// checkPortListening verifies that something listens on the given port inside the environment.
+// When strict is false, failures are logged as warnings and nil is returned.
+func checkPortListening(ctx context.Context, client *coder.Client, env *coder.Environment, port int, strict bool) error {
+	var ports []int
+	err := withAPITimeout(ctx, func(ctx context.Context) error {
+		var err error
+		ports, err = listeningPorts(ctx, client, env.ID)
+		return err
+	})
+	if err != nil {
+		if strict {
+			return xerrors.Errorf("check port %v: %w", port, err)
+		}
+		clog.LogWarn(fmt.Sprintf("unable to check port %v", port), clog.Causef("%v", err))
+		return nil
+	}
+	for _, p := range ports {
+		if p == port {
+			return nil
+		}
+	}
+
+	msg := fmt.Sprintf("nothing is listening on port %v in environment %q", port, env.Name)
+	if strict {
+		return clog.Error(msg, clog.Tipf("start your service first or remove --strict to create the devurl anyway"))
+	}
+	clog.LogWarn(msg, clog.Tipf("the devurl will not respond until a service listens on port %v", port))
+	return nil
+}
+
+// processPort returns the port listened on by the process with the given name inside the environment.
+// A process listening on several ports is an error unless first is set, which picks the lowest one.
+func processPort(ctx context.Context, client *coder.Client, env *coder.Environment, name string, first bool) (int, error) {
+	var ports []int
+	err := withAPITimeout(ctx, func(ctx context.Context) (err error) {
+		ports, err = processListeningPorts(ctx, client, env.ID, name)
+		return err
+	})
+	if err != nil {
+		return 0, xerrors.Errorf("find the ports of process %q: %w", name, err)
+	}
+	switch {
+	case len(ports) == 0:
+		return 0, clog.Error(
+			fmt.Sprintf("no process named %q is listening in environment %q", name, env.Name),
+			clog.Tipf("start your service first, or pass its port instead of --port-from-process"),
+		)
+	case len(ports) > 1 && !first:
+		return 0, clog.Error(
+			fmt.Sprintf("process %q is listening on several ports", name),
+			fmt.Sprintf("it listens on the ports %v", ports),
+			clog.BlankLine,
+			clog.Tipf("pass one of the ports instead of --port-from-process, or use --first to pick the lowest one"),
+		)
+	}
+	clog.LogInfo(fmt.Sprintf("using port %v of process %q", ports[0], name))
+	return ports[0], nil
+}
+
+// devURLPollInterval is the delay between two readiness or deletion checks of a devURL.
+// Readiness checks start with it, backing off up to devURLMaxPollInterval.
+var devURLPollInterval = time.Second
+
+// devURLMaxPollInterval caps the backoff between two readiness checks of a devURL.
+var devURLMaxPollInterval = 15 * time.Second
+
+// devURLPollBackoff returns the delay after the given readiness check attempt, starting from 1: devURLPollInterval
+// doubled on every attempt up to devURLMaxPollInterval, with a random jitter of up to half of it.
+func devURLPollBackoff(attempt int) time.Duration {
+	backoff := devURLPollInterval
+	for i := 1; i < attempt && backoff < devURLMaxPollInterval; i++ {
+		backoff *= 2
+	}
+	if backoff > devURLMaxPollInterval {
+		backoff = devURLMaxPollInterval
+	}
+	half := int64(backoff / 2)
+	if half < 1 {
+		return backoff
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// waitForDevURL polls the given devURL address until it responds with a non-5xx status code,
+// backing off between attempts. Connection errors mean the service isn't up yet, but failing
+// to resolve the address is an error.
+func waitForDevURL(ctx context.Context, address string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Don't follow redirects, e.g. to the login page, as any non-5xx response means the service is up.
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	clog.LogInfo(fmt.Sprintf("waiting for %s to respond", address))
+	for attempt := 1; ; attempt++ {
+		status, err := pollDevURL(ctx, client, address)
+		if err == nil && status < http.StatusInternalServerError {
+			clog.LogSuccess(fmt.Sprintf("%s responded with status %d", address, status))
+			return nil
+		}
+		var dnsErr *net.DNSError
+		if xerrors.As(err, &dnsErr) {
+			return clog.Error(
+				fmt.Sprintf("failed to resolve %s", address),
+				clog.Causef(err.Error()), clog.BlankLine,
+				clog.Tipf("check that the DNS records of the devurls are set up, or create the devurl without --wait"),
+			)
+		}
+		if err == nil {
+			err = xerrors.Errorf("status %d", status)
+		}
+		backoff := devURLPollBackoff(attempt)
+		if verbose {
+			clog.LogInfo(fmt.Sprintf("attempt %d: devurl not ready yet, retrying in %s", attempt, backoff.Round(time.Millisecond)), clog.Causef(err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			if xerrors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return clog.Error(
+					fmt.Sprintf("timed out after %s waiting for %s to respond", timeout, address),
+					clog.Causef(err.Error()), clog.BlankLine,
+					clog.Tipf("use \"--wait-timeout\" to wait longer"),
+				)
+			}
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// pollDevURL issues a single GET request to the given address and returns the response status code.
+func pollDevURL(ctx context.Context, client *http.Client, address string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }() // Best effort.
+	return resp.StatusCode, nil
+}
+
+// devURLNameValidRx is the regex used to validate devurl names specified
+// via the --name subcommand. Named devurls must begin with a letter, end
+// with a letter or digit, and consist solely of letters, digits, hyphens
+// and underscores, with a max length of 64 chars.
+var devURLNameValidRx = regexp.MustCompile("^[a-zA-Z]([a-zA-Z0-9_-]{0,62}[a-zA-Z0-9])?$")
+
+// devURLNameRequirements describes the names matched by devURLNameValidRx.
+const devURLNameRequirements = "name must be at most 64 chars in length, begin with a letter, end with a letter or digit and only contain letters, digits, hyphens or underscores."
+
+// devURLID returns the ID of a devURL, given the env name and port
+// from a list of DevURL records.
+// ("", false) is returned if no match is found.
+func devURLID(port int, urls []coder.DevURL) (string, bool) {
+	url, found := devURLByPort(port, urls)
+	if !found {
+		return "", false
+	}
+	return url.ID, true
+}
+
+// devURLByPort returns the DevURL record for the given port
+// from a list of DevURL records.
+func devURLByPort(port int, urls []coder.DevURL) (*coder.DevURL, bool) {
+	for _, url := range urls {
+		if url.Port == port {
+			return &url, true
+		}
+	}
+	return nil, false
+}
+
+// devURLByName returns the DevURL record with the given name
+// from a list of DevURL records.
+func devURLByName(name string, urls []coder.DevURL) (*coder.DevURL, bool) {
+	for _, url := range urls {
+		if url.Name == name {
+			return &url, true
+		}
+	}
+	return nil, false
+}
+
+// resolveDevURL finds the devURL referenced by target, which is either a devURL
+// name or a port. An exact name match takes precedence over a port match.
+func resolveDevURL(target string, urls []coder.DevURL) (*coder.DevURL, error) {
+	portNum, numErr := strconv.Atoi(target)
+
+	if url, found := devURLByName(target, urls); found {
+		if numErr == nil {
+			if portURL, found := devURLByPort(portNum, urls); found && portURL.ID != url.ID {
+				clog.LogInfo(fmt.Sprintf("%q matches both a devurl name and a port, using the devurl named %q", target, target))
+			}
+		}
+		return url, nil
+	}
+
+	if numErr != nil {
+		return nil, xerrors.Errorf("No devurl found with name %q", target)
+	}
+	if _, err := validatePort(target); err != nil {
+		return nil, xerrors.Errorf("validate port: %w", err)
+	}
+	url, found := devURLByPort(portNum, urls)
+	if !found {
+		return nil, xerrors.Errorf("No devurl found for port %v", target)
+	}
+	return url, nil
+}
+
+func openDevURLCmd() *cobra.Command {
+	var (
+		outputFmt string
+		pretty    bool
+		printURL  bool
+	)
+	cmd := &cobra.Command{
+		Use:               "open [env_name] [port]",
+		Short:             "Open a devurl in the default browser",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: getDevURLPortsForCompletion(false),
+		Example: `coder urls open my-env 8080
+coder urls open my-env 8080 --print`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				envName = args[0]
+				port    = args[1]
+				ctx     = cmd.Context()
+			)
+
+			portNum, err := validatePort(port)
+			if err != nil {
+				return err
+			}
+
+			client, err := newClientWithTimeout(ctx)
+			if err != nil {
+				return err
+			}
+
+			urls, err := urlList(ctx, client, envName)
+			if err != nil {
+				return err
+			}
+
+			devURL, found := devURLByPort(portNum, urls)
+			if !found {
+				return xerrors.Errorf("No devurl found for port %v", port)
+			}
+
+			switch outputFmt {
+			case humanOutput:
+				target := devURLAddress(client, devURL.URL)
+				if printURL {
+					fmt.Println(target)
+					return nil
+				}
+				// The browser launcher may print, which must not mix with the results on stdout.
+				browser.Stdout = os.Stderr
+				if err := browser.OpenURL(target); err != nil {
+					return xerrors.Errorf("open browser: %w", err)
+				}
+			case jsonOutput:
+				if err := newJSONEncoder(os.Stdout, pretty).Encode(timedDevURL{DevURL: *devURL, Timings: outputTimings()}); err != nil {
+					return xerrors.Errorf("encode DevURL as json: %w", err)
+				}
+			default:
+				return xerrors.Errorf("unknown --output value %q", outputFmt)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&outputFmt, "output", "o", humanOutput, "human|json")
+	cmd.Flags().BoolVar(&pretty, "pretty", false, "indent json output")
+	cmd.Flags().BoolVar(&printURL, "print", false, "print the devurl to stdout instead of opening it")
+	return cmd
+}
+
+// accessSummary is the number of devURLs with a given access level.
+type accessSummary struct {
+	Access      string `table:"Access"`
+	Count       int    `table:"Count,right"`
+	Description string `table:"Description"`
+}
+
+func schemaDevURLsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the devurls listed by urls ls --output json",
+		Long: `Print the JSON Schema of the devurls listed by urls ls --output json.
+
+The schema is derived from the fields of the devurls, so it always matches the output of this version of coder.`,
+		Args:    cobra.NoArgs,
+		Example: `coder urls schema > devurls.schema.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema := jsonSchemaOf(reflect.TypeOf([]devURLRecord{}))
+			schema.Schema, schema.Title = jsonSchemaDraft, "coder urls ls --output json"
+			if err := newJSONEncoder(os.Stdout, true).Encode(schema); err != nil {
+				return xerrors.Errorf("encode schema as json: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+func summarizeDevURLsCmd() *cobra.Command {
+	var (
+		outputFmt string
+		pretty    bool
+		all       bool
+	)
+	cmd := &cobra.Command{
+		Use:   "summary [env_name]",
+		Short: "Count the devurls of an environment by access level",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		ValidArgsFunction: getDevURLEnvsForCompletion,
+		Example: `coder urls summary my-env
+coder urls summary --all --output json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if outputFmt != humanOutput && outputFmt != jsonOutput {
+				return xerrors.Errorf("unknown --output value %q", outputFmt)
+			}
+
+			client, err := newClientWithTimeout(ctx)
+			if err != nil {
+				return err
+			}
+
+			var urls []coder.DevURL
+			if all {
+				records, err := allEnvsDevURLs(ctx, client, func(urls []coder.DevURL) []coder.DevURL { return urls })
+				if err != nil {
+					return err
+				}
+				for _, record := range records {
+					urls = append(urls, record.DevURL)
+				}
+			} else if urls, err = urlList(ctx, client, args[0]); err != nil {
+				return err
+			}
+
+			counts := make(map[string]int, len(accessLevelsByExposure))
+			for _, level := range accessLevelsByExposure {
+				counts[level] = 0
+			}
+			for _, url := range urls {
+				counts[strings.ToUpper(url.Access)]++
+			}
+
+			if outputFmt == jsonOutput {
+				if err := newJSONEncoder(os.Stdout, pretty).Encode(counts); err != nil {
+					return xerrors.Errorf("encode summary as json: %w", err)
+				}
+				return nil
+			}
+
+			summary := make([]accessSummary, 0, len(counts))
+			for _, level := range accessLevelsByExposure {
+				summary = append(summary, accessSummary{Access: level, Count: counts[level], Description: urlAccessLevel[level]})
+			}
+			// Levels unknown to this version of the CLI are still accounted for.
+			var unknown []string
+			for level := range counts {
+				if _, ok := urlAccessLevel[level]; !ok {
+					unknown = append(unknown, level)
+				}
+			}
+			sort.Strings(unknown)
+			for _, level := range unknown {
+				summary = append(summary, accessSummary{Access: level, Count: counts[level]})
+			}
+			return tablewriter.WriteTable(len(summary), func(i int) interface{} { return summary[i] })
+		},
+	}
+	cmd.Flags().StringVarP(&outputFmt, "output", "o", humanOutput, "human|json")
+	cmd.Flags().BoolVar(&pretty, "pretty", false, "indent json output")
+	cmd.Flags().BoolVar(&all, "all", false, "count the devurls of all of your environments")
+	return cmd
+}
+
+func getDevURLCmd() *cobra.Command {
+	var (
+		outputFmt string
+		pretty    bool
+	)
+	cmd := &cobra.Command{
+		Use:               "get [env_name] [port|name]",
+		Short:             "Show the details of a single devurl",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: getDevURLPortsForCompletion(false),
+		Example: `coder urls get my-env 8080
+coder urls get my-env frontend --output json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				envName = args[0]
+				target  = args[1]
+				ctx     = cmd.Context()
+			)
+
+			if outputFmt != humanOutput && outputFmt != jsonOutput {
+				return xerrors.Errorf("unknown --output value %q", outputFmt)
+			}
+
+			client, err := newClientWithTimeout(ctx)
+			if err != nil {
+				return err
+			}
+
+			urls, err := urlList(ctx, client, envName)
+			if err != nil {
+				return err
+			}
+
+			devURL, err := resolveDevURL(target, urls)
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == jsonOutput {
+				if err := newJSONEncoder(os.Stdout, pretty).Encode(timedDevURL{DevURL: *devURL, Timings: outputTimings()}); err != nil {
+					return xerrors.Errorf("encode DevURL as json: %w", err)
+				}
+				return nil
+			}
+			return tablewriter.WriteTable(1, func(int) interface{} { return *devURL }, tablewriter.ShowHidden("Name", "Scheme"))
+		},
+	}
+	cmd.Flags().StringVarP(&outputFmt, "output", "o", humanOutput, "human|json")
+	cmd.Flags().BoolVar(&pretty, "pretty", false, "indent json output")
+	return cmd
+}
+
+func renameDevURLCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "rename [env_name] [port] [new_name]",
+		Short:             "Change the name of a devurl, keeping its access level and scheme",
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: getDevURLPortsForCompletion(false),
+		Example:           `coder urls rename my-env 8080 frontend`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				envName = args[0]
+				port    = args[1]
+				name    = args[2]
+				ctx     = cmd.Context()
+			)
+
+			portNum, err := validatePort(port)
+			if err != nil {
+				return err
+			}
+			if !devURLNameValidRx.MatchString(name) {
+				return xerrors.Errorf("rename devurl: %s", devURLNameRequirements)
+			}
+
+			client, err := newClientWithTimeout(ctx)
+			if err != nil {
+				return err
+			}
+			env, err := findEnvWithTimeout(ctx, client, envName)
+			if err != nil {
+				return err
+			}
+			urls, err := urlListForEnv(ctx, client, env)
+			if err != nil {
+				return err
+			}
+			devURL, found := devURLByPort(portNum, urls)
+			if !found {
+				return xerrors.Errorf("No devurl found for port %v", port)
+			}
+			if devURL.Name == name {
+				clog.LogInfo(fmt.Sprintf("devurl for port %v is already named %q", port, name))
+				return nil
+			}
+			if devURL.Wildcard {
+				return clog.Error(
+					"wildcard devurls cannot be named",
+					clog.Causef("the subdomains of a wildcard devurl are derived from its port"),
+					clog.Tipf("make it a plain devurl with \"coder urls create %s %v --wildcard=false --name %s\"", envName, port, name),
+				)
+			}
+
+			req := coder.PutDevURLReq{
+				Port:     devURL.Port,
+				Name:     name,
+				Access:   devURL.Access,
+				EnvID:    env.ID,
+				Scheme:   devURL.Scheme,
+				Wildcard: devURL.Wildcard,
+			}
+			start := time.Now()
+			err = withAPIRetries(ctx, func(ctx context.Context) error {
+				return client.PutDevURL(ctx, env.ID, devURL.ID, req)
+			})
+			recordTiming("rename devurl", start)
+			if err != nil {
+				return xerrors.Errorf("update DevURL: %w", err)
+			}
+			clog.LogSuccess(fmt.Sprintf("renamed devurl for port %v to %q", port, name))
+			return nil
+		},
+	}
+}
+
+// devURLAddress gives an absolute address for the given devURL host,
+// defaulting to the scheme of the Coder Enterprise deployment.
+func devURLAddress(client *coder.Client, rawURL string) string {
+	if strings.Contains(rawURL, "://") {
+		return rawURL
+	}
+	return client.BaseURL.Scheme + "://" + rawURL
+}
+
+type removeDevURLOptions struct {
+	all         bool
+	yes         bool
+	dryRun      bool
+	wait        bool
+	waitTimeout time.Duration
+	outputFmt   string
+	pretty      bool
+}
+
+// devURLDeletion is the json output of urls rm for each deleted devURL.
+type devURLDeletion struct {
+	Deleted coder.DevURL `json:"deleted"`
+	// Timings are the timings of the command when --timings is set.
+	Timings []apiTiming `json:"timings,omitempty"`
+}
+
+// writeDevURLDeletions writes a devURLDeletion for each of the urls which were deleted, in json output.
+func writeDevURLDeletions(outputFmt string, pretty bool, urls []coder.DevURL, deleted []bool) error {
+	if outputFmt != jsonOutput {
+		return nil
+	}
+	var (
+		enc     = newJSONEncoder(os.Stdout, pretty)
+		timings = outputTimings()
+	)
+	for i, url := range urls {
+		if !deleted[i] {
+			continue
+		}
+		if err := enc.Encode(devURLDeletion{Deleted: url, Timings: timings}); err != nil {
+			return xerrors.Errorf("encode deleted DevURL as json: %w", err)
+		}
+	}
+	return nil
+}
+
+func removeDevURLCmd() *cobra.Command {
+	var opts removeDevURLOptions
+	rmArgs := func(cmd *cobra.Command, args []string) error {
+		if opts.all {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(2)(cmd, args)
+	}
+	cmd := &cobra.Command{
+		Use:   "rm [environment_name] [port|name]...",
+		Short: "Remove a dev url",
+		Long: `Remove one or more dev urls of an environment.
+
+When several ports or names are given, the ones without a devurl are skipped with a warning.`,
+		Example: `coder urls rm my-env 8080
+coder urls rm my-env frontend
+coder urls rm my-env 8080 9090 3000
+coder urls rm my-env --all --yes`,
+		Args:              withEnvPicker(rmArgs),
+		ValidArgsFunction: getDevURLPortsForCompletion(false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			args, err := pickEnvArg(cmd, args, rmArgs)
+			if err != nil {
+				return err
+			}
+			if opts.outputFmt != humanOutput && opts.outputFmt != jsonOutput {
+				return xerrors.Errorf("unknown --output value %q", opts.outputFmt)
+			}
+			if opts.all {
+				return removeAllDevURLs(cmd, args[0], opts)
+			}
+			return removeDevURL(cmd, args, opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.all, "all", false, "remove every devurl of the environment")
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "remove without prompting for confirmation")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "print the requests which would be sent instead of removing devurls")
+	cmd.Flags().BoolVar(&opts.wait, "wait", false, "wait for the devurls to be gone from the environment before exiting")
+	cmd.Flags().DurationVar(&opts.waitTimeout, "wait-timeout", time.Minute, "maximum time to wait for the devurls to be gone")
+	cmd.Flags().StringVarP(&opts.outputFmt, "output", "o", humanOutput, "human|json, json writes each deleted devurl as {\"deleted\": {...}}")
+	cmd.Flags().BoolVar(&opts.pretty, "pretty", false, "indent json output")
+	return cmd
+}
+
+// Run deletes devURLs, specified by env ID and ports or names, from the cemanager.
+func removeDevURL(cmd *cobra.Command, args []string, opts removeDevURLOptions) error {
+	var (
+		envName = args[0]
+		targets = args[1:]
+		ctx     = cmd.Context()
+	)
+
+	client, err := newClientWithTimeout(ctx)
+	if err != nil {
+		return err
+	}
+	env, err := findEnvWithTimeout(ctx, client, envName)
+	if err != nil {
+		return err
+	}
+
+	urls, err := urlListForEnv(ctx, client, env)
+	if err != nil {
+		return err
+	}
+
+	devURLs, err := resolveDevURLs(targets, urls)
+	if err != nil {
+		return err
+	}
+	if opts.dryRun {
+		runs := make([]devURLDryRun, 0, len(devURLs))
+		for _, devURL := range devURLs {
+			runs = append(runs, devURLDryRun{Method: http.MethodDelete, EnvID: env.ID, DevURLID: devURL.ID})
+		}
+		return writeDryRuns(opts.outputFmt, opts.pretty, runs)
+	}
+
+	var (
+		egroup  = clog.LoggedErrGroup()
+		records = make([]coder.DevURL, len(devURLs))
+		deleted = make([]bool, len(devURLs))
+	)
+	for i, devURL := range devURLs {
+		i, devURL := i, devURL
+		records[i] = *devURL
+		egroup.Go(func() error {
+			clog.LogInfo(fmt.Sprintf("deleting devurl for port %v", devURL.Port))
+			start := time.Now()
+			err := deleteDevURLWithRetries(ctx, client, env.ID, devURL.ID)
+			recordTiming(fmt.Sprintf("delete devurl for port %v", devURL.Port), start)
+			if err != nil {
+				return xerrors.Errorf("delete DevURL for port %v: %w", devURL.Port, err)
+			}
+			deleted[i] = true
+			return nil
+		})
+	}
+	deleteErr := egroup.Wait()
+	if err := writeDevURLDeletions(opts.outputFmt, opts.pretty, records, deleted); err != nil {
+		return err
+	}
+	if deleteErr != nil {
+		return deleteErr
+	}
+	if opts.wait {
+		ports := make([]int, 0, len(devURLs))
+		for _, devURL := range devURLs {
+			ports = append(ports, devURL.Port)
+		}
+		return waitForDevURLsDeletion(ctx, client, env, ports, opts.waitTimeout)
+	}
+	return nil
+}
+
+// resolveDevURLs finds the unique devURLs referenced by targets, like resolveDevURL. With several targets,
+// the ones which match no devURL are skipped with a warning, and only matching none of them is an error.
+func resolveDevURLs(targets []string, urls []coder.DevURL) ([]*coder.DevURL, error) {
+	if len(targets) == 1 {
+		devURL, err := resolveDevURL(targets[0], urls)
+		if err != nil {
+			return nil, err
+		}
+		return []*coder.DevURL{devURL}, nil
+	}
+
+	seen := make(map[string]bool, len(targets))
+	devURLs := make([]*coder.DevURL, 0, len(targets))
+	for _, target := range targets {
+		devURL, err := resolveDevURL(target, urls)
+		if err != nil {
+			clog.LogWarn(fmt.Sprintf("skipping %q", target), clog.Causef(err.Error()))
+			continue
+		}
+		if seen[devURL.ID] {
+			continue
+		}
+		seen[devURL.ID] = true
+		devURLs = append(devURLs, devURL)
+	}
+	if len(devURLs) < 1 {
+		return nil, xerrors.Errorf("No devurl found for %q", targets)
+	}
+	return devURLs, nil
+}
+
+// removeAllDevURLs deletes every devURL of the given environment, continuing past
+// individual failures.
+func removeAllDevURLs(cmd *cobra.Command, envName string, opts removeDevURLOptions) error {
+	ctx := cmd.Context()
+
+	client, err := newClientWithTimeout(ctx)
+	if err != nil {
+		return err
+	}
+	env, err := findEnvWithTimeout(ctx, client, envName)
+	if err != nil {
+		return err
+	}
+
+	urls, err := urlListForEnv(ctx, client, env)
+	if err != nil {
+		return err
+	}
+	if len(urls) < 1 {
+		clog.LogInfo(fmt.Sprintf("no devURLs found for environment %q", envName))
+		return nil
+	}
+
+	if opts.dryRun {
+		runs := make([]devURLDryRun, 0, len(urls))
+		for _, url := range urls {
+			runs = append(runs, devURLDryRun{Method: http.MethodDelete, EnvID: env.ID, DevURLID: url.ID})
+		}
+		return writeDryRuns(opts.outputFmt, opts.pretty, runs)
+	}
+
+	if !opts.yes {
+		confirm := promptui.Prompt{
+			Label:     fmt.Sprintf("Delete all %d devurls of environment %q?", len(urls), envName),
+			IsConfirm: true,
+		}
+		if _, err := confirm.Run(); err != nil {
+			return clog.Fatal(
+				"failed to confirm deletion", clog.BlankLine,
+				clog.Tipf(`use "--yes" to remove without a confirmation prompt`),
+			)
+		}
+	}
+
+	var (
+		egroup  = clog.LoggedErrGroup()
+		deleted = make([]bool, len(urls))
+	)
+	for i, url := range urls {
+		i, url := i, url
+		egroup.Go(func() error {
+			start := time.Now()
+			err := deleteDevURLWithRetries(ctx, client, env.ID, url.ID)
+			recordTiming(fmt.Sprintf("delete devurl for port %v", url.Port), start)
+			if err != nil {
+				return clog.Error(
+					fmt.Sprintf("failed to delete devurl for port %v", url.Port),
+					clog.Causef(err.Error()),
+				)
+			}
+			clog.LogInfo(fmt.Sprintf("deleted devurl for port %v", url.Port))
+			deleted[i] = true
+			return nil
+		})
+	}
+	deleteErr := egroup.Wait()
+	if err := writeDevURLDeletions(opts.outputFmt, opts.pretty, urls, deleted); err != nil {
+		return err
+	}
+	if deleteErr != nil {
+		return deleteErr
+	}
+	if opts.wait {
+		ports := make([]int, 0, len(urls))
+		for _, url := range urls {
+			ports = append(ports, url.Port)
+		}
+		return waitForDevURLsDeletion(ctx, client, env, ports, opts.waitTimeout)
+	}
+	return nil
+}
+
+// waitForDevURLsDeletion polls the devURLs of the environment until none is left for the given ports.
+func waitForDevURLsDeletion(ctx context.Context, client *coder.Client, env *coder.Environment, ports []int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	clog.LogInfo(fmt.Sprintf("waiting for %d devurl(s) to be gone", len(ports)))
+	for attempt := 1; ; attempt++ {
+		urls, err := urlListForEnv(ctx, client, env)
+		if err == nil {
+			var remaining []int
+			for _, port := range ports {
+				if _, found := devURLID(port, urls); found {
+					remaining = append(remaining, port)
+				}
+			}
+			if len(remaining) < 1 {
+				clog.LogSuccess("devurls are gone")
+				return nil
+			}
+			err = xerrors.Errorf("devurls still exist for ports %v", remaining)
+		}
+		clog.LogInfo(fmt.Sprintf("attempt %d: devurls not gone yet", attempt), clog.Causef(err.Error()))
+
+		select {
+		case <-ctx.Done():
+			if xerrors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return clog.Error(
+					fmt.Sprintf("timed out after %s waiting for the devurls to be gone", timeout),
+					clog.Causef(err.Error()), clog.BlankLine,
+					clog.Tipf("use \"--wait-timeout\" to wait longer"),
+				)
+			}
+			return ctx.Err()
+		case <-time.After(devURLPollInterval):
+		}
+	}
+}
+
+// getDevURLPortsForCompletion completes the environment name, then the ports of its existing devURLs.
+// When withListening is set, the ports listened on inside the environment and the common development
+// ports are also suggested, sorted, followed by "auto".
+func getDevURLPortsForCompletion(withListening bool) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return getDevURLEnvsForCompletion(cmd, args, toComplete)
+		}
+		if len(args) > 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		ctx := completionContext(cmd)
+		client, err := newClientWithTimeout(ctx)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		env, err := findEnvWithTimeout(ctx, client, args[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		seen := map[int]bool{}
+		var ports []int
+		addPort := func(port int) {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+
+		// Errors are ignored so that a partial list can still be completed.
+		if urls, err := urlListForEnv(ctx, client, env); err == nil {
+			for _, url := range urls {
+				addPort(url.Port)
+			}
+		}
+		if withListening {
+			_ = withAPITimeout(ctx, func(ctx context.Context) error {
+				listening, err := listeningPorts(ctx, client, env.ID)
+				for _, port := range listening {
+					addPort(port)
+				}
+				return err
+			})
+			for _, port := range commonDevPorts {
+				addPort(port)
+			}
+			sort.Ints(ports)
+		}
+
+		completions := make([]string, 0, len(ports)+1)
+		for _, port := range ports {
+			completions = append(completions, strconv.Itoa(port))
+		}
+		if withListening {
+			completions = append(completions, autoPortArg)
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// urlList returns the list of active devURLs from the cemanager.
+func urlList(ctx context.Context, client *coder.Client, envName string) ([]coder.DevURL, error) {
+	env, err := findEnvWithTimeout(ctx, client, envName)
+	if err != nil {
+		return nil, err
+	}
+	return urlListForEnv(ctx, client, env)
+}
+
+// urlListForEnv returns the list of active devURLs of an already resolved environment.
+func urlListForEnv(ctx context.Context, client *coder.Client, env *coder.Environment) ([]coder.DevURL, error) {
+	defer recordTiming("list devurls", time.Now())
+	var devURLs []coder.DevURL
+	err := withAPIRetries(ctx, func(ctx context.Context) (err error) {
+		devURLs, err = client.DevURLs(ctx, env.ID)
+		return err
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("list DevURLs: %w", devURLUserError(err))
+	}
+	devURLs, duplicates := dedupDevURLs(devURLs)
+	if duplicates > 0 {
+		clog.LogWarn(fmt.Sprintf("ignoring %d duplicate devurl(s) listed for environment %q", duplicates, env.Name))
+	}
+	return devURLs, nil
+}
+
+// dedupDevURLs drops the devURLs with the same ID as a previous one, which the server may
+// list during replication lag, and returns how many were dropped.
+func dedupDevURLs(urls []coder.DevURL) ([]coder.DevURL, int) {
+	seen := make(map[string]bool, len(urls))
+	deduped := make([]coder.DevURL, 0, len(urls))
+	for _, url := range urls {
+		if url.ID != "" && seen[url.ID] {
+			continue
+		}
+		seen[url.ID] = true
+		deduped = append(deduped, url)
+	}
+	return deduped, len(urls) - len(deduped)
+}
+
+// defaultAPITimeout is the default value of the --timeout flag of the urls commands.
+const defaultAPITimeout = 30 * time.Second
+
+// apiTimeout bounds each API request made by the urls commands.
+var apiTimeout = defaultAPITimeout
+
+// withAPITimeout calls fn with a context bounded by apiTimeout, giving a clear
+// error when the deadline is exceeded or the command is canceled.
+func withAPITimeout(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	err := fn(ctx)
+	switch {
+	case err == nil:
+		return nil
+	case xerrors.Is(ctx.Err(), context.DeadlineExceeded):
+		return clog.Error(
+			fmt.Sprintf("request timed out after %s", apiTimeout), clog.BlankLine,
+			clog.Tipf(`use "--timeout" to allow more time`),
+		)
+	case xerrors.Is(ctx.Err(), context.Canceled):
+		return clog.Error("request canceled")
+	}
+	return err
+}
+
+// defaultAPIRetries is the default value of the --retries flag of the urls commands.
+const defaultAPIRetries = 3
+
+var (
+	// apiRetries is the maximum number of retries of a failed devURL request.
+	apiRetries = defaultAPIRetries
+	// apiRetryBackoff is the delay before the first retry, doubled on every following one.
+	apiRetryBackoff = 500 * time.Millisecond
+)
+
+// withAPIRetries calls fn like withAPITimeout, retrying up to apiRetries times with an
+// exponential backoff when fn fails with a network error or a 5xx response.
+func withAPIRetries(ctx context.Context, fn func(ctx context.Context) error) error {
+	backoff := apiRetryBackoff
+	for retry := 1; ; retry++ {
+		var retryable bool
+		err := withAPITimeout(ctx, func(ctx context.Context) error {
+			err := fn(ctx)
+			retryable = isRetryableAPIError(err)
+			return err
+		})
+		if err == nil || !retryable || retry > apiRetries || ctx.Err() != nil {
+			return err
+		}
+
+		clog.LogWarn(fmt.Sprintf("request failed, retrying in %s (%d/%d)", backoff, retry, apiRetries), clog.Causef(err.Error()))
+		select {
+		case <-ctx.Done():
+			return clog.Error("request canceled")
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// createDevURLWithRetries creates a devURL like withAPIRetries, without creating it twice when a failed
+// attempt reached the cemanager anyway, e.g. when only its response was lost: before each retry, the devURLs
+// are listed again and an existing devURL on the port is updated instead.
+func createDevURLWithRetries(ctx context.Context, client *coder.Client, envID string, req coder.CreateDevURLReq) error {
+	attempt := 0
+	return withAPIRetries(ctx, func(ctx context.Context) error {
+		attempt++
+		if attempt > 1 {
+			urls, err := client.DevURLs(ctx, envID)
+			if err != nil {
+				return err
+			}
+			if url, found := devURLByPort(req.Port, urls); found {
+				return client.PutDevURL(ctx, envID, url.ID, coder.PutDevURLReq(req))
+			}
+		}
+		return client.CreateDevURL(ctx, envID, req)
+	})
+}
+
+// deleteDevURLWithRetries deletes a devURL like withAPIRetries. A failed attempt may have deleted the devURL
+// anyway, so the devURL missing on a retry counts as deleted.
+func deleteDevURLWithRetries(ctx context.Context, client *coder.Client, envID, urlID string) error {
+	attempt := 0
+	return withAPIRetries(ctx, func(ctx context.Context) error {
+		attempt++
+		err := client.DeleteDevURL(ctx, envID, urlID)
+		var httpErr *coder.HTTPError
+		if attempt > 1 && xerrors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+// createDevURLAutoPortWithRetries creates a devURL on a free port like createDevURLWithRetries. As the port is
+// only known once allocated, a devURL created by a failed attempt is found by name before each retry. Unnamed
+// devURLs can't be found that way, so their creation is never retried.
+func createDevURLAutoPortWithRetries(ctx context.Context, client *coder.Client, envID string, req coder.CreateDevURLReq) (*coder.DevURL, error) {
+	var created *coder.DevURL
+	if req.Name == "" {
+		err := withAPITimeout(ctx, func(ctx context.Context) (err error) {
+			created, err = client.CreateDevURLAutoPort(ctx, envID, req)
+			return err
+		})
+		return created, err
+	}
+
+	attempt := 0
+	err := withAPIRetries(ctx, func(ctx context.Context) error {
+		attempt++
+		if attempt > 1 {
+			urls, err := client.DevURLs(ctx, envID)
+			if err != nil {
+				return err
+			}
+			if url, found := devURLByName(req.Name, urls); found {
+				created = url
+				return nil
+			}
+		}
+		var err error
+		created, err = client.CreateDevURLAutoPort(ctx, envID, req)
+		return err
+	})
+	return created, err
+}
+
+// isRetryableAPIError reports whether a request failing with err may succeed when sent again.
+func isRetryableAPIError(err error) bool {
+	var httpErr *coder.HTTPError
+	if xerrors.As(err, &httpErr) {
+		return httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return xerrors.As(err, &netErr)
+}
+
+// newClientWithTimeout creates a new client, bounding the authentication check by apiTimeout.
+func newClientWithTimeout(ctx context.Context) (*coder.Client, error) {
+	var client *coder.Client
+	err := withAPITimeout(ctx, func(ctx context.Context) (err error) {
+		client, err = newClient(ctx)
+		return err
+	})
+	return client, err
+}
+
+// findEnvWithTimeout finds an environment of the authenticated user by name, bounding the lookup by apiTimeout.
+// Stopped environments are an error since their devURLs can't be managed.
+func findEnvWithTimeout(ctx context.Context, client *coder.Client, envName string) (*coder.Environment, error) {
+	if devURLEnvID != "" {
+		// NOTE: The environment is not fetched to save a round trip, so its ID stands in for its name.
+		return &coder.Environment{ID: devURLEnvID, Name: devURLEnvID}, nil
+	}
+	owner, envName, err := splitEnvOwner(envName)
+	if err != nil {
+		return nil, err
+	}
+	if owner != "" && devURLUser != coder.Me {
+		return nil, clog.Error(
+			"an owner-qualified environment name can't be used with --user",
+			clog.Tipf("remove the %q prefix, or the --user flag", owner+"/"),
+		)
+	}
+	defer recordTiming("find environment", time.Now())
+	var env *coder.Environment
+	err = withAPITimeout(ctx, func(ctx context.Context) (err error) {
+		user := devURLUser
+		if owner != "" {
+			if user, err = lookupOrgMember(ctx, client, owner); err != nil {
+				return err
+			}
+		}
+		if !devURLExactEnv {
+			env, err = findEnvByPrefix(ctx, client, envName, user, devURLOrg)
+			return err
+		}
+		env, err = findOrgEnv(ctx, client, envName, user, devURLOrg)
+		return err
+	})
+	if err != nil {
+		return nil, devURLUserError(err)
+	}
+	if env.LatestStat.ContainerStatus == coder.EnvironmentOff {
+		return nil, clog.Error(
+			fmt.Sprintf("environment %q is stopped", env.Name),
+			"devurls are unavailable until it is running",
+			clog.BlankLine,
+			clog.Tipf("start it with \"coder envs rebuild %s\"", env.Name),
+		)
+	}
+	return env, nil
+}
+
+// devURLUser is the user whose devURLs are targeted by the urls commands.
+var devURLUser = coder.Me
+
+// splitEnvOwner splits an environment name of the form owner/name into its owner and name.
+// The owner is empty when the name isn't owner-qualified.
+func splitEnvOwner(envName string) (owner, name string, err error) {
+	i := strings.Index(envName, "/")
+	if i < 0 {
+		return "", envName, nil
+	}
+	owner, name = envName[:i], envName[i+1:]
+	if owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", xerrors.Errorf("invalid environment name %q, expected the form owner/name", envName)
+	}
+	return owner, name, nil
+}
+
+// devURLEnvID is the ID of the environment targeted by the urls commands, which
+// replaces the environment name argument when set.
+var devURLEnvID string
+
+// withEnvIDArg makes the leading environment name argument of cmd optional when --env-id is set,
+// passing the ID in its place. Giving both is an error.
+func withEnvIDArg(cmd *cobra.Command) {
+	validate, run := cmd.Args, cmd.RunE
+	if validate == nil || run == nil {
+		return
+	}
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if devURLEnvID == "" {
+			return validate(cmd, args)
+		}
+		err := validate(cmd, append([]string{devURLEnvID}, args...))
+		if err != nil && validate(cmd, args) == nil {
+			return clog.Error(
+				"the environment name argument can't be used with --env-id",
+				clog.Tipf("remove the environment name, or the --env-id flag"),
+			)
+		}
+		return err
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if devURLEnvID != "" {
+			args = append([]string{devURLEnvID}, args...)
+		}
+		return run(cmd, args)
+	}
+}
+
+// defaultEnvEnv is the environment variable setting the default environment of the urls commands.
+const defaultEnvEnv = "CODER_DEFAULT_ENV"
+
+// defaultEnvName returns the name of the default environment of the urls commands, from
+// $CODER_DEFAULT_ENV or else the config file, or an empty string when none is set.
+func defaultEnvName() string {
+	if name := os.Getenv(defaultEnvEnv); name != "" {
+		return name
+	}
+	// The config file is optional, so read errors mean there is no default.
+	name, _ := config.DefaultEnv.Read()
+	return strings.TrimSpace(name)
+}
+
+// withDefaultEnvArg makes the leading environment name argument of cmd optional when a default
+// environment is set, passing its name in place of the missing argument.
+func withDefaultEnvArg(cmd *cobra.Command) {
+	validate, run := cmd.Args, cmd.RunE
+	if validate == nil || run == nil {
+		return
+	}
+	missingEnv := func(cmd *cobra.Command, args []string, name string) bool {
+		return name != "" && validate(cmd, args) != nil && validate(cmd, append([]string{name}, args...)) == nil
+	}
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if name := defaultEnvName(); missingEnv(cmd, args, name) {
+			return nil
+		}
+		return validate(cmd, args)
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if name := defaultEnvName(); missingEnv(cmd, args, name) {
+			clog.LogInfo(fmt.Sprintf("using the default environment %q", name))
+			args = append([]string{name}, args...)
+		}
+		return run(cmd, args)
+	}
+}
+
+// devURLOrg is the organization, by name or ID, in which the urls commands look the environments up.
+// All of the user's organizations are searched when empty.
+var devURLOrg string
+
+// devURLExactEnv requires the environment names given to the urls commands to match exactly,
+// instead of allowing a unique prefix.
+var devURLExactEnv = true
+
+// devURLUserError clarifies the authorization errors of requests targeting another user.
+func devURLUserError(err error) error {
+	var httpErr *coder.HTTPError
+	if devURLUser == coder.Me || !xerrors.As(err, &httpErr) {
+		return err
+	}
+	if httpErr.StatusCode != http.StatusUnauthorized && httpErr.StatusCode != http.StatusForbidden {
+		return err
+	}
+	return clog.Error(
+		fmt.Sprintf("not authorized to access the devurls of user %q", devURLUser),
+		clog.Causef(err.Error()), clog.BlankLine,
+		clog.Tipf("managing the devurls of other users requires an admin role"),
+	)
+}
+
+// getDevURLEnvsForCompletion completes the environments of the user targeted by the urls commands.
+func getDevURLEnvsForCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// NOTE: The user and org are only known once the flags are parsed, which happens right before completion.
+	ctx := completionContext(cmd)
+	client, err := newClient(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	envs, _, err := getOrgEnvs(ctx, client, devURLUser, devURLOrg)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return envNames(envs), cobra.ShellCompDirectiveDefault
 }
@@ -0,0 +1,2630 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cdr.dev/slog/sloggers/slogtest/assert"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v2"
+
+	"cdr.dev/coder-cli/coder-sdk"
+	"cdr.dev/coder-cli/pkg/clog"
+)
+
+const (
+	fakeUserID = "fake-user-id"
+	fakeOrgID  = "fake-org-id"
+	fakeEnvID  = "fake-env-id"
+
+	// fakeOtherOrgID is a second organization of the authenticated user, without environments by default.
+	fakeOtherOrgID = "fake-other-org-id"
+
+	// fakeOtherUserID is a user other than the authenticated one, only reachable by ID.
+	fakeOtherUserID = "fake-other-user-id"
+
+	// fakeAutoPort is the port allocated by the fake cemanager for auto port requests.
+	fakeAutoPort = 49152
+
+	// fakeTokenID is the ID of fakeSessionToken, a session token in the format issued by Coder.
+	fakeTokenID      = "fake0token"
+	fakeSessionToken = fakeTokenID + "-fake0secret0of0the0tok"
+)
+
+// fakeCemanager is an in-memory stand-in for the cemanager API endpoints
+// used by the urls commands.
+type fakeCemanager struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	devURLs  []coder.DevURL
+	requests []fakeRequest
+	// envs and otherOrgEnvs are the environments of the authenticated user in each organization.
+	envs         []coder.Environment
+	otherOrgEnvs []coder.Environment
+
+	// envLookups counts the requests listing the environments.
+	envLookups int32
+	// lostCreates is the number of next devURL creations whose response is replaced by a server error.
+	lostCreates int
+	// lostDeletes is the number of next devURL deletions whose response is replaced by a server error.
+	lostDeletes int
+	// tokenExpiresAt is the expiry of the session token, which is unknown when nil.
+	tokenExpiresAt *time.Time
+}
+
+// fakeRequest records a mutating request received by the fakeCemanager.
+type fakeRequest struct {
+	Method string
+	Path   string
+	Body   coder.CreateDevURLReq
+}
+
+func newFakeCemanager(t *testing.T, devURLs ...coder.DevURL) *fakeCemanager {
+	// Copy the devURLs as the fake mutates them.
+	f := &fakeCemanager{
+		devURLs: append([]coder.DevURL(nil), devURLs...),
+		envs:    []coder.Environment{{ID: fakeEnvID, Name: "env1", OrganizationID: fakeOrgID}},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/private/users/me", func(w http.ResponseWriter, r *http.Request) {
+		writeFakeJSON(w, coder.User{ID: fakeUserID, Email: "user@coder.com"})
+	})
+	mux.HandleFunc("/api/private/users/"+fakeOtherUserID, func(w http.ResponseWriter, r *http.Request) {
+		writeFakeJSON(w, coder.User{ID: fakeOtherUserID, Email: "other@coder.com"})
+	})
+	mux.HandleFunc("/api/private/users", func(w http.ResponseWriter, r *http.Request) {
+		// Only admins may list users.
+		http.Error(w, `{"error":{"msg":"insufficient permissions"}}`, http.StatusForbidden)
+	})
+	mux.HandleFunc("/api/private/orgs/"+fakeOrgID+"/members/"+fakeOtherUserID+"/environments", func(w http.ResponseWriter, r *http.Request) {
+		writeFakeJSON(w, []coder.Environment{{ID: fakeEnvID, Name: "other-env"}})
+	})
+	mux.HandleFunc("/api/private/orgs", func(w http.ResponseWriter, r *http.Request) {
+		writeFakeJSON(w, []coder.Organization{{
+			ID:   fakeOrgID,
+			Name: "default",
+			Members: []coder.OrganizationUser{
+				{User: coder.User{ID: fakeUserID, Username: "user", Email: "user@coder.com"}},
+				{User: coder.User{ID: fakeOtherUserID, Username: "other", Email: "other@coder.com"}},
+			},
+		}, {
+			ID:      fakeOtherOrgID,
+			Name:    "other",
+			Members: []coder.OrganizationUser{{User: coder.User{ID: fakeUserID}}},
+		}})
+	})
+	mux.HandleFunc("/api/private/orgs/"+fakeOrgID+"/members/"+fakeUserID+"/environments", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&f.envLookups, 1)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		writeFakeJSON(w, f.envs)
+	})
+	mux.HandleFunc("/api/private/orgs/"+fakeOtherOrgID+"/members/"+fakeUserID+"/environments", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		writeFakeJSON(w, append([]coder.Environment{}, f.otherOrgEnvs...))
+	})
+	mux.HandleFunc("/api/environments/"+fakeEnvID+"/devurls", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		writeFakeJSON(w, f.devURLs)
+	})
+	mux.HandleFunc("/api/private/api-keys/me/"+fakeTokenID, func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		writeFakeJSON(w, coder.APIToken{ID: fakeTokenID, UserID: fakeUserID, ExpiresAt: f.tokenExpiresAt})
+	})
+	mux.HandleFunc("/api/private/environments/"+fakeEnvID+"/devurls", f.handleMutation)
+	mux.HandleFunc("/api/private/environments/"+fakeEnvID+"/devurls/", f.handleMutation)
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+
+	setFakeEnv(t, "CODER_URL", f.URL)
+	setFakeEnv(t, "CODER_TOKEN", "fake-token")
+	return f
+}
+
+func (f *fakeCemanager) handleMutation(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	req := fakeRequest{Method: r.Method, Path: r.URL.Path}
+	if r.Method != http.MethodDelete {
+		if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	f.requests = append(f.requests, req)
+
+	urlID := path.Base(r.URL.Path)
+	switch r.Method {
+	case http.MethodPost:
+		port := req.Body.Port
+		if req.Body.AutoPort {
+			port = fakeAutoPort
+		}
+		devURL := coder.DevURL{
+			ID:       fmt.Sprintf("url-%d", len(f.requests)),
+			URL:      fmt.Sprintf("%d.coder.com", port),
+			Port:     port,
+			Access:   req.Body.Access,
+			Name:     req.Body.Name,
+			Scheme:   req.Body.Scheme,
+			Wildcard: req.Body.Wildcard,
+		}
+		f.devURLs = append(f.devURLs, devURL)
+		if f.lostCreates > 0 {
+			f.lostCreates--
+			http.Error(w, `{"error":{"msg":"bad gateway"}}`, http.StatusBadGateway)
+			return
+		}
+		writeFakeJSON(w, devURL)
+		return
+	case http.MethodPut:
+		for i, url := range f.devURLs {
+			if url.ID == urlID {
+				f.devURLs[i].Port, f.devURLs[i].Access = req.Body.Port, req.Body.Access
+				f.devURLs[i].Name, f.devURLs[i].Scheme = req.Body.Name, req.Body.Scheme
+				f.devURLs[i].Wildcard = req.Body.Wildcard
+			}
+		}
+	case http.MethodDelete:
+		found := false
+		for i, url := range f.devURLs {
+			if url.ID == urlID {
+				f.devURLs = append(f.devURLs[:i], f.devURLs[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			http.Error(w, `{"error":{"msg":"devurl not found"}}`, http.StatusNotFound)
+			return
+		}
+		if f.lostDeletes > 0 {
+			f.lostDeletes--
+			http.Error(w, `{"error":{"msg":"bad gateway"}}`, http.StatusBadGateway)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Requests returns the mutating requests received so far.
+func (f *fakeCemanager) Requests() []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeRequest(nil), f.requests...)
+}
+
+func writeFakeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v) // Best effort.
+}
+
+func setFakeEnv(t *testing.T, key, value string) {
+	prev, ok := os.LookupEnv(key)
+	assert.Success(t, "set "+key, os.Setenv(key, value))
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, prev)
+			return
+		}
+		_ = os.Unsetenv(key)
+	})
+}
+
+// runCmd executes the root command with the given arguments.
+func runCmd(t *testing.T, args ...string) error {
+	t.Helper()
+	app := Make()
+	app.SetArgs(args)
+	return ExecuteContext(context.Background(), app)
+}
+
+// captureStderr runs fn, returning everything it wrote to stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	assert.Success(t, "create pipe", err)
+
+	//! clearly not thread safe
+	stderr := os.Stderr
+	os.Stderr = writer
+	defer func() { os.Stderr = stderr }()
+
+	fn()
+	writer.Close()
+
+	output, err := ioutil.ReadAll(reader)
+	assert.Success(t, "read all stderr output", err)
+	return string(output)
+}
+
+// captureStdout runs fn, returning everything it wrote to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	assert.Success(t, "create pipe", err)
+
+	//! clearly not thread safe
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	writer.Close()
+
+	output, err := ioutil.ReadAll(reader)
+	assert.Success(t, "read all stdout output", err)
+	return string(output)
+}
+
+func TestCreateDevURLScheme(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		f := newFakeCemanager(t)
+		err := runCmd(t, "urls", "create", "env1", "8080", "--name", "web")
+		assert.Success(t, "create devurl", err)
+
+		reqs := f.Requests()
+		assert.Equal(t, "request count", 1, len(reqs))
+		assert.Equal(t, "method", http.MethodPost, reqs[0].Method)
+		assert.Equal(t, "scheme", "http", reqs[0].Body.Scheme)
+	})
+
+	t.Run("https", func(t *testing.T) {
+		f := newFakeCemanager(t)
+		err := runCmd(t, "urls", "create", "env1", "8080", "--name", "web", "--scheme", "HTTPS")
+		assert.Success(t, "create devurl", err)
+
+		reqs := f.Requests()
+		assert.Equal(t, "request count", 1, len(reqs))
+		assert.Equal(t, "scheme", "https", reqs[0].Body.Scheme)
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		f := newFakeCemanager(t, coder.DevURL{ID: "url-id", Port: 8080, Name: "web", Access: "PRIVATE"})
+		err := runCmd(t, "urls", "edit", "env1", "8080", "--name", "web", "--scheme", "https")
+		assert.Success(t, "edit devurl", err)
+
+		reqs := f.Requests()
+		assert.Equal(t, "request count", 1, len(reqs))
+		assert.Equal(t, "method", http.MethodPut, reqs[0].Method)
+		assert.True(t, "updates existing devurl", strings.HasSuffix(reqs[0].Path, "/url-id"))
+		assert.Equal(t, "scheme", "https", reqs[0].Body.Scheme)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		f := newFakeCemanager(t)
+		err := runCmd(t, "urls", "create", "env1", "8080", "--name", "web", "--scheme", "ftp")
+		assert.Error(t, "create devurl", err)
+		assert.Equal(t, "request count", 0, len(f.Requests()))
+	})
+}
+
+func TestOpenDevURL(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", Port: 8080, URL: "web.coder.com", Access: "PRIVATE"})
+
+	t.Run("print", func(t *testing.T) {
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "open", "env1", "8080", "--print")
+		})
+		assert.Success(t, "open devurl", err)
+		assert.Equal(t, "printed url", "http://web.coder.com\n", output)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "open", "env1", "8080", "-o", "json")
+		})
+		assert.Success(t, "open devurl", err)
+
+		var devURL coder.DevURL
+		assert.Success(t, "unmarshal devurl", json.Unmarshal([]byte(output), &devURL))
+		assert.Equal(t, "devurl id", "url-id", devURL.ID)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		err := runCmd(t, "urls", "open", "env1", "9090", "--print")
+		assert.Error(t, "open devurl", err)
+	})
+}
+
+func TestListDevURLsAccessFilter(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "private-id", Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "public-id", Port: 9090, Access: "PUBLIC"},
+	)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "json", "--access", "public")
+	})
+	assert.Success(t, "list devurls", err)
+
+	var devURLs []coder.DevURL
+	assert.Success(t, "unmarshal devurls", json.Unmarshal([]byte(output), &devURLs))
+	assert.Equal(t, "devurl count", 1, len(devURLs))
+	assert.Equal(t, "devurl id", "public-id", devURLs[0].ID)
+
+	err = runCmd(t, "urls", "ls", "env1", "--access", "nobody")
+	assert.Error(t, "invalid access level", err)
+}
+
+func TestListDevURLsOutputEnv(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "web-id", Port: 8080, Access: "PRIVATE", Name: "web"})
+
+	setFakeEnv(t, "CODER_OUTPUT", "json")
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1")
+	})
+	assert.Success(t, "list devurls", err)
+	var devURLs []coder.DevURL
+	assert.Success(t, "default json output", json.Unmarshal([]byte(output), &devURLs))
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "human")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.True(t, "explicit human output", strings.HasPrefix(output, "URL"))
+
+	setFakeEnv(t, "CODER_OUTPUT", "xml")
+	stderr := captureStderr(t, func() {
+		output = captureStdout(t, func() {
+			err = runCmd(t, "urls", "ls", "env1")
+		})
+	})
+	assert.Success(t, "list devurls", err)
+	assert.True(t, "warns about the invalid format", strings.Contains(stderr, `invalid CODER_OUTPUT "xml"`))
+	assert.True(t, "falls back to human output", strings.HasPrefix(output, "URL"))
+}
+
+func TestListDevURLsJSONPath(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "web-id", URL: "web.coder.com", Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "api-id", URL: "api.coder.com", Port: 3000, Access: "PUBLIC"},
+	)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "--json-path", "$[*].url")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.Equal(t, "urls sorted by port", "api.coder.com\nweb.coder.com\n", output)
+
+	err = runCmd(t, "urls", "ls", "env1", "--json-path", "$[*].url", "-o", "yaml")
+	assert.Error(t, "json path with yaml output", err)
+	err = runCmd(t, "urls", "ls", "env1", "--json-path", "url")
+	assert.Error(t, "invalid json path", err)
+}
+
+func TestListDevURLsDuplicates(t *testing.T) {
+	web := coder.DevURL{ID: "web-id", Port: 8080, Access: "PRIVATE", Name: "web"}
+	newFakeCemanager(t, web, coder.DevURL{ID: "api-id", Port: 3000, Access: "PRIVATE"}, web)
+
+	var (
+		err    error
+		output string
+	)
+	stderr := captureStderr(t, func() {
+		output = captureStdout(t, func() {
+			err = runCmd(t, "urls", "ls", "env1", "-o", "json")
+		})
+	})
+	assert.Success(t, "list devurls", err)
+	assert.True(t, "warns about the duplicates", strings.Contains(stderr, `ignoring 1 duplicate devurl(s) listed for environment "env1"`))
+
+	var devURLs []coder.DevURL
+	assert.Success(t, "unmarshal devurls", json.Unmarshal([]byte(output), &devURLs))
+	assert.Equal(t, "devurl count", 2, len(devURLs))
+}
+
+func TestListDevURLsReachable(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+	gone := httptest.NewServer(http.NotFoundHandler())
+	gone.Close()
+
+	newFakeCemanager(t,
+		coder.DevURL{ID: "up-id", URL: up.URL, Port: 3000, Access: "PRIVATE"},
+		coder.DevURL{ID: "down-id", URL: down.URL, Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "gone-id", URL: gone.URL, Port: 9090, Access: "PRIVATE"},
+	)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "--reachable", "-o", "json")
+	})
+	assert.Success(t, "list devurls", err)
+	var records []devURLRecord
+	assert.Success(t, "unmarshal devurls", json.Unmarshal([]byte(output), &records))
+	assert.Equal(t, "devurl count", 3, len(records))
+	assert.True(t, "up reachable", *records[0].Reachable && *records[0].Status == http.StatusNoContent)
+	assert.True(t, "down unreachable", !*records[1].Reachable && *records[1].Status == http.StatusBadGateway)
+	assert.True(t, "gone unreachable", !*records[2].Reachable && records[2].Status == nil)
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "--reachable")
+	})
+	assert.Success(t, "list devurls", err)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Equal(t, "header", []string{"URL", "Port", "Access", "Reachable", "Status"}, strings.Fields(lines[0]))
+	assert.Equal(t, "up row", []string{up.URL, "3000", "PRIVATE", "true", "204"}, strings.Fields(lines[1]))
+	assert.Equal(t, "gone row", []string{gone.URL, "9090", "PRIVATE", "false"}, strings.Fields(lines[3]))
+}
+
+func TestListDevURLsOnlyAccess(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "private-id", Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "public-id", Port: 9090, Access: "PUBLIC"},
+	)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "json", "--only-private")
+	})
+	assert.Success(t, "list devurls", err)
+
+	var devURLs []coder.DevURL
+	assert.Success(t, "unmarshal devurls", json.Unmarshal([]byte(output), &devURLs))
+	assert.Equal(t, "devurl count", 1, len(devURLs))
+	assert.Equal(t, "devurl id", "private-id", devURLs[0].ID)
+
+	err = runCmd(t, "urls", "ls", "env1", "--only-public", "--only-org")
+	assert.Error(t, "combined shorthands", err)
+	err = runCmd(t, "urls", "ls", "env1", "--only-public", "--access", "public")
+	assert.Error(t, "shorthand with --access", err)
+}
+
+func TestRemoveAllDevURLs(t *testing.T) {
+	f := newFakeCemanager(t,
+		coder.DevURL{ID: "first-id", Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "second-id", Port: 9090, Access: "PUBLIC"},
+	)
+
+	err := runCmd(t, "urls", "rm", "env1", "--all", "--yes")
+	assert.Success(t, "remove all devurls", err)
+
+	reqs := f.Requests()
+	assert.Equal(t, "request count", 2, len(reqs))
+	for _, req := range reqs {
+		assert.Equal(t, "method", http.MethodDelete, req.Method)
+	}
+}
+
+func TestRemoveDevURLs(t *testing.T) {
+	f := newFakeCemanager(t,
+		coder.DevURL{ID: "first-id", Port: 8080, Access: "PRIVATE", Name: "web"},
+		coder.DevURL{ID: "second-id", Port: 9090, Access: "PUBLIC"},
+		coder.DevURL{ID: "third-id", Port: 3000, Access: "PUBLIC"},
+	)
+
+	var err error
+	stderr := captureStderr(t, func() {
+		err = runCmd(t, "urls", "rm", "env1", "8080", "web", "4000", "9090")
+	})
+	assert.Success(t, "remove devurls", err)
+	assert.True(t, "warns about the missing port", strings.Contains(stderr, `skipping "4000"`))
+
+	reqs := f.Requests()
+	assert.Equal(t, "request count", 2, len(reqs))
+	paths := []string{reqs[0].Path, reqs[1].Path}
+	sort.Strings(paths)
+	assert.Equal(t, "deleted devurls", []string{
+		"/api/private/environments/" + fakeEnvID + "/devurls/first-id",
+		"/api/private/environments/" + fakeEnvID + "/devurls/second-id",
+	}, paths)
+
+	err = runCmd(t, "urls", "rm", "env1", "4000", "5000")
+	assert.Error(t, "no matching devurl", err)
+	assert.Equal(t, "request count", 2, len(f.Requests()))
+}
+
+func TestRemoveDevURLOutput(t *testing.T) {
+	first := coder.DevURL{ID: "first-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"}
+	second := coder.DevURL{ID: "second-id", URL: "9090.coder.com", Port: 9090, Access: "PUBLIC"}
+	newFakeCemanager(t, first, second)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "rm", "env1", "8080", "-o", "json")
+	})
+	assert.Success(t, "remove devurl", err)
+	var deletion map[string]coder.DevURL
+	assert.Success(t, "unmarshal deletion", json.Unmarshal([]byte(output), &deletion))
+	assert.Equal(t, "deleted devurl", map[string]coder.DevURL{"deleted": first}, deletion)
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "urls", "rm", "env1", "--all", "--yes", "-o", "json")
+	})
+	assert.Success(t, "remove all devurls", err)
+	assert.Equal(t, "deleted devurls", `{"deleted":{"id":"second-id","url":"9090.coder.com","port":9090,"access":"PUBLIC","name":"","scheme":""}}`+"\n", output)
+
+	err = runCmd(t, "urls", "rm", "env1", "8080", "-o", "yaml")
+	assert.Error(t, "unsupported output", err)
+}
+
+func TestRemoveDevURLWait(t *testing.T) {
+	f := newFakeCemanager(t,
+		coder.DevURL{ID: "first-id", Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "second-id", Port: 9090, Access: "PUBLIC"},
+	)
+
+	err := runCmd(t, "urls", "rm", "env1", "8080", "--wait", "--wait-timeout", "5s")
+	assert.Success(t, "remove devurl", err)
+	assert.Equal(t, "request count", 1, len(f.Requests()))
+
+	err = runCmd(t, "urls", "rm", "env1", "--all", "--yes", "--wait")
+	assert.Success(t, "remove all devurls", err)
+	assert.Equal(t, "request count", 2, len(f.Requests()))
+}
+
+func TestResolveDevURL(t *testing.T) {
+	urls := []coder.DevURL{
+		{ID: "web-id", Port: 8080, Name: "web"},
+		{ID: "numeric-id", Port: 3000, Name: "9090"},
+		{ID: "api-id", Port: 9090, Name: "api"},
+	}
+
+	for _, scene := range []struct {
+		name   string
+		target string
+		wantID string
+	}{
+		{"by-port", "8080", "web-id"},
+		{"by-name", "api", "api-id"},
+		{"name-over-port", "9090", "numeric-id"},
+	} {
+		scene := scene
+		t.Run(scene.name, func(t *testing.T) {
+			url, err := resolveDevURL(scene.target, urls)
+			assert.Success(t, "resolve devurl", err)
+			assert.Equal(t, "devurl id", scene.wantID, url.ID)
+		})
+	}
+
+	_, err := resolveDevURL("missing", urls)
+	assert.Error(t, "unknown name", err)
+	_, err = resolveDevURL("4000", urls)
+	assert.Error(t, "unknown port", err)
+}
+
+func TestListDevURLsYAML(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", Port: 8080, URL: "web.coder.com", Access: "PRIVATE"})
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "yaml")
+	})
+	assert.Success(t, "list devurls", err)
+
+	var devURLs []coder.DevURL
+	assert.Success(t, "unmarshal devurls", yaml.Unmarshal([]byte(output), &devURLs))
+	assert.Equal(t, "devurls", []coder.DevURL{{ID: "url-id", Port: 8080, URL: "web.coder.com", Access: "PRIVATE"}}, devURLs)
+}
+
+func TestListAllEnvsDevURLs(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "private-id", Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "public-id", Port: 9090, Access: "PUBLIC"},
+	)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "--all", "--access", "public", "-o", "json")
+	})
+	assert.Success(t, "list devurls", err)
+
+	var devURLs []devURLRecord
+	assert.Success(t, "unmarshal devurls", json.Unmarshal([]byte(output), &devURLs))
+	assert.Equal(t, "devurls", []devURLRecord{{
+		Environment: "env1",
+		DevURL:      coder.DevURL{ID: "public-id", Port: 9090, Access: "PUBLIC"},
+	}}, devURLs)
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "--all")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.True(t, "has environment column", strings.HasPrefix(output, "Environment"))
+}
+
+func TestWaitForDevURL(t *testing.T) {
+	devURLPollInterval = time.Millisecond
+
+	t.Run("ready", func(t *testing.T) {
+		var attempts int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+
+		err := waitForDevURL(context.Background(), srv.URL, time.Second)
+		assert.Success(t, "wait for devurl", err)
+		assert.Equal(t, "attempts", int32(3), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		err := waitForDevURL(context.Background(), srv.URL, 20*time.Millisecond)
+		assert.Error(t, "wait for devurl", err)
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		err := waitForDevURL(context.Background(), srv.URL, 20*time.Millisecond)
+		assert.ErrorContains(t, "keeps polling until the timeout", err, "timed out")
+	})
+
+	t.Run("unresolvable", func(t *testing.T) {
+		err := waitForDevURL(context.Background(), "http://devurl.invalid", time.Second)
+		assert.ErrorContains(t, "resolution errors are fatal", err, "failed to resolve")
+	})
+}
+
+func TestDevURLPollBackoff(t *testing.T) {
+	devURLPollInterval, devURLMaxPollInterval = time.Second, 10*time.Second
+	defer func() { devURLPollInterval, devURLMaxPollInterval = time.Millisecond, 15*time.Second }()
+
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 50: 10 * time.Second} {
+		for i := 0; i < 20; i++ {
+			got := devURLPollBackoff(attempt)
+			assert.True(t, fmt.Sprintf("backoff %s of attempt %d is at most %s", got, attempt, want), got <= want)
+			assert.True(t, fmt.Sprintf("backoff %s of attempt %d is at least half of %s", got, attempt, want), got >= want/2)
+		}
+	}
+}
+
+func TestWithAPITimeout(t *testing.T) {
+	apiTimeout = time.Millisecond
+	defer func() { apiTimeout = defaultAPITimeout }()
+
+	err := withAPITimeout(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.Error(t, "request times out", err)
+	assert.True(t, "timeout error", strings.Contains(err.Error(), "timed out"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = withAPITimeout(ctx, func(ctx context.Context) error { return ctx.Err() })
+	assert.True(t, "canceled error", strings.Contains(err.Error(), "canceled"))
+}
+
+func TestListDevURLsNameFilter(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "staging-id", Port: 8080, Name: "api-staging", Access: "PRIVATE"},
+		coder.DevURL{ID: "prod-id", Port: 9090, Name: "api-prod", Access: "PRIVATE"},
+		coder.DevURL{ID: "web-id", Port: 3000, Name: "web", Access: "PRIVATE"},
+	)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "--name", "api-*")
+	})
+	assert.Success(t, "list devurls", err)
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Equal(t, "row count", 3, len(lines))
+	assert.True(t, "has name column", strings.Contains(lines[0], "Name"))
+	assert.True(t, "excludes unmatched", !strings.Contains(output, "web"))
+
+	err = runCmd(t, "urls", "ls", "env1", "--name", "[")
+	assert.Error(t, "invalid pattern", err)
+}
+
+func TestListDevURLsOlderThan(t *testing.T) {
+	t.Run("dated", func(t *testing.T) {
+		old, recent := time.Now().Add(-48*time.Hour), time.Now().Add(-time.Hour)
+		newFakeCemanager(t,
+			coder.DevURL{ID: "old-id", Port: 8080, Name: "old", Access: "PRIVATE", CreatedAt: &old},
+			coder.DevURL{ID: "recent-id", Port: 9090, Name: "recent", Access: "PRIVATE", CreatedAt: &recent},
+		)
+
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "ls", "env1", "--older-than", "24h", "-o", "json")
+		})
+		assert.Success(t, "list devurls", err)
+		var urls []coder.DevURL
+		assert.Success(t, "decode devurls", json.Unmarshal([]byte(output), &urls))
+		assert.Equal(t, "devurl count", 1, len(urls))
+		assert.Equal(t, "old devurl", "old-id", urls[0].ID)
+	})
+
+	t.Run("undated", func(t *testing.T) {
+		newFakeCemanager(t, coder.DevURL{ID: "web-id", Port: 8080, Name: "web", Access: "PRIVATE"})
+
+		var err error
+		var output string
+		stderr := captureStderr(t, func() {
+			output = captureStdout(t, func() {
+				err = runCmd(t, "urls", "ls", "env1", "--older-than", "24h", "-o", "json")
+			})
+		})
+		assert.Success(t, "list devurls", err)
+		assert.True(t, "warns about the missing timestamps", strings.Contains(stderr, "ignoring --older-than"))
+		assert.True(t, "keeps the devurls", strings.Contains(output, "web-id"))
+	})
+}
+
+func TestCreateDevURLOutput(t *testing.T) {
+	t.Run("human", func(t *testing.T) {
+		newFakeCemanager(t)
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "8080", "--name", "web")
+		})
+		assert.Success(t, "create devurl", err)
+		assert.Equal(t, "printed url", "http://8080.coder.com\n", output)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		newFakeCemanager(t)
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "8080", "--name", "web", "-o", "json")
+		})
+		assert.Success(t, "create devurl", err)
+
+		var devURL coder.DevURL
+		assert.Success(t, "unmarshal devurl", json.Unmarshal([]byte(output), &devURL))
+		assert.Equal(t, "devurl port", 8080, devURL.Port)
+		assert.Equal(t, "devurl name", "web", devURL.Name)
+	})
+
+	t.Run("print-id", func(t *testing.T) {
+		newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "9090.coder.com", Port: 9090, Access: "PRIVATE"})
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "8080", "--print-id", "--quiet")
+			assert.Success(t, "create devurl", err)
+			err = runCmd(t, "urls", "create", "env1", "9090", "--print-id")
+		})
+		assert.Success(t, "update devurl", err)
+		assert.Equal(t, "printed ids", "url-1\nurl-id\n", output)
+
+		err = runCmd(t, "urls", "create", "env1", "8080", "--print-id", "-o", "json")
+		assert.Error(t, "print-id with json output", err)
+	})
+
+	t.Run("print-id auto port", func(t *testing.T) {
+		newFakeCemanager(t)
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "auto", "--print-id")
+		})
+		assert.Success(t, "create devurl", err)
+		assert.Equal(t, "printed id", "url-1\n", output)
+	})
+
+	t.Run("print-id missing", func(t *testing.T) {
+		newFakeCemanager(t, coder.DevURL{URL: "9090.coder.com", Port: 9090, Access: "PRIVATE"})
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "9090", "--print-id")
+		})
+		assert.Error(t, "devurl without id", err)
+		assert.Equal(t, "no id printed", "", output)
+	})
+}
+
+func TestCreateDevURLAutoPort(t *testing.T) {
+	for _, args := range [][]string{{"auto"}, {"0", "--auto-port"}} {
+		args := args
+		t.Run(args[0], func(t *testing.T) {
+			fake := newFakeCemanager(t)
+			var err error
+			output := captureStdout(t, func() {
+				err = runCmd(t, append([]string{"urls", "create", "env1", "--name", "preview"}, args...)...)
+			})
+			assert.Success(t, "create devurl", err)
+			assert.Equal(t, "printed url", fmt.Sprintf("http://%d.coder.com\n", fakeAutoPort), output)
+
+			requests := fake.Requests()
+			assert.Equal(t, "requests", 1, len(requests))
+			assert.True(t, "auto port requested", requests[0].Body.AutoPort)
+			assert.Equal(t, "no port sent", 0, requests[0].Body.Port)
+		})
+	}
+
+	t.Run("zero without flag", func(t *testing.T) {
+		newFakeCemanager(t)
+		err := runCmd(t, "urls", "create", "env1", "0", "--name", "preview")
+		assert.Error(t, "create devurl", err)
+	})
+}
+
+func TestDevURLPortsCompletion(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "url-1", Port: 8080, Access: "PRIVATE", Name: "web"},
+		coder.DevURL{ID: "url-2", Port: 3000, Access: "ORG", Name: "api"},
+	)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "__complete", "urls", "rm", "env1", "")
+	})
+	assert.Success(t, "complete ports", err)
+	assert.Equal(t, "completed ports", []string{"8080", "3000", ":4"}, strings.Fields(output))
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "__complete", "urls", "create", "env1", "")
+	})
+	assert.Success(t, "complete create ports", err)
+	assert.Equal(t, "completed create ports", []string{
+		"3000", "4200", "5000", "5173", "8000", "8080", "8443", "8888", "9000", autoPortArg, ":4",
+	}, strings.Fields(output))
+}
+
+func TestAccessLevelCompletion(t *testing.T) {
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "__complete", "urls", "create", "env1", "8080", "--access", "")
+	})
+	assert.Success(t, "complete access levels", err)
+	assert.Equal(t, "completed access levels", []string{
+		"authed\t" + urlAccessLevel["AUTHED"],
+		"org\t" + urlAccessLevel["ORG"],
+		"private\t" + urlAccessLevel["PRIVATE"],
+		"public\t" + urlAccessLevel["PUBLIC"],
+		":4",
+	}, strings.Split(strings.TrimSpace(output), "\n"))
+}
+
+func TestListDevURLsDescribe(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "public-id", Port: 9090, Access: "PUBLIC", Name: "web"})
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "json")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.True(t, "no description by default", !strings.Contains(output, "description"))
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "json", "--describe")
+	})
+	assert.Success(t, "list devurls", err)
+	var devURLs []devURLRecord
+	assert.Success(t, "unmarshal devurls", json.Unmarshal([]byte(output), &devURLs))
+	assert.Equal(t, "description", urlAccessLevel["PUBLIC"], devURLs[0].Description)
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "--describe")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.True(t, "has description column", strings.Contains(output, "Description"))
+	assert.True(t, "has description", strings.Contains(output, urlAccessLevel["PUBLIC"]))
+}
+
+func TestCreateDevURLUpdateIfExists(t *testing.T) {
+	existing := coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE", Name: "web", Scheme: "http"}
+
+	t.Run("default updates", func(t *testing.T) {
+		fake := newFakeCemanager(t, existing)
+		captureStdout(t, func() {
+			err := runCmd(t, "urls", "create", "env1", "8080", "--name", "web", "--access", "org")
+			assert.Success(t, "create devurl", err)
+		})
+		requests := fake.Requests()
+		assert.Equal(t, "requests", 1, len(requests))
+		assert.Equal(t, "method", http.MethodPut, requests[0].Method)
+	})
+
+	t.Run("keeps access", func(t *testing.T) {
+		fake := newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PUBLIC", Name: "web", Scheme: "http"})
+		captureStdout(t, func() {
+			err := runCmd(t, "urls", "edit", "env1", "8080", "--name", "frontend")
+			assert.Success(t, "edit devurl", err)
+		})
+		requests := fake.Requests()
+		assert.Equal(t, "requests", 1, len(requests))
+		assert.Equal(t, "access", "PUBLIC", requests[0].Body.Access)
+		assert.Equal(t, "name", "frontend", requests[0].Body.Name)
+	})
+
+	t.Run("disabled fails", func(t *testing.T) {
+		fake := newFakeCemanager(t, existing)
+		err := runCmd(t, "urls", "create", "env1", "8080", "--name", "web", "--update-if-exists=false")
+		assert.Error(t, "create devurl", err)
+		assert.Equal(t, "requests", 0, len(fake.Requests()))
+	})
+}
+
+func TestGetDevURL(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "url-1", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE", Name: "web", Scheme: "http"},
+		coder.DevURL{ID: "url-2", URL: "3000.coder.com", Port: 3000, Access: "ORG", Name: "api", Scheme: "https"},
+	)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "get", "env1", "api", "-o", "json")
+	})
+	assert.Success(t, "get devurl", err)
+	var devURL coder.DevURL
+	assert.Success(t, "unmarshal devurl", json.Unmarshal([]byte(output), &devURL))
+	assert.Equal(t, "devurl id", "url-2", devURL.ID)
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "urls", "get", "env1", "8080")
+	})
+	assert.Success(t, "get devurl", err)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Equal(t, "table rows", 2, len(lines))
+	assert.True(t, "shows name", strings.Contains(lines[1], "web"))
+
+	err = runCmd(t, "urls", "get", "env1", "9090")
+	assert.Error(t, "get missing devurl", err)
+}
+
+func TestCreateUnnamedDevURL(t *testing.T) {
+	fake := newFakeCemanager(t)
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "create", "env1", "8080")
+	})
+	assert.Success(t, "create devurl", err)
+	assert.Equal(t, "printed url", "http://8080.coder.com\n", output)
+
+	requests := fake.Requests()
+	assert.Equal(t, "requests", 1, len(requests))
+	assert.Equal(t, "no name sent", "", requests[0].Body.Name)
+}
+
+func TestDevURLNameValidRx(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "web", valid: true},
+		{name: "W", valid: true},
+		{name: "web2", valid: true},
+		{name: "user-service", valid: true},
+		{name: "api_gateway", valid: true},
+		{name: "a-b_c-1", valid: true},
+		{name: "a" + strings.Repeat("b", 63), valid: true},
+		{name: "a" + strings.Repeat("b", 64), valid: false},
+		{name: "", valid: false},
+		{name: "2web", valid: false},
+		{name: "-web", valid: false},
+		{name: "_web", valid: false},
+		{name: "web-", valid: false},
+		{name: "web_", valid: false},
+		{name: "web.app", valid: false},
+		{name: "web app", valid: false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, fmt.Sprintf("valid %q", test.name), test.valid, devURLNameValidRx.MatchString(test.name))
+	}
+}
+
+func TestListDevURLsWide(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE", Name: "web", Scheme: "https"})
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.True(t, "human output hides ID", !strings.Contains(output, "url-id"))
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "wide")
+	})
+	assert.Success(t, "list devurls", err)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Equal(t, "table rows", 2, len(lines))
+	assert.Equal(t, "header", []string{"Environment", "ID", "URL", "Port", "Access", "Name", "Scheme", "Wildcard", "CreatedAt", "UpdatedAt", "Description", "Reachable", "Status"}, strings.Fields(lines[0]))
+	for _, value := range []string{"env1", "url-id", "web", "https"} {
+		assert.True(t, "wide output shows "+value, strings.Contains(lines[1], value))
+	}
+}
+
+func TestListDevURLsJSONLines(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "url-1", Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "url-2", Port: 3000, Access: "ORG"},
+	)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "json-lines")
+	})
+	assert.Success(t, "list devurls", err)
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Equal(t, "lines", 2, len(lines))
+	for i, id := range []string{"url-2", "url-1"} {
+		var devURL coder.DevURL
+		assert.Success(t, "unmarshal devurl", json.Unmarshal([]byte(lines[i]), &devURL))
+		assert.Equal(t, "devurl id", id, devURL.ID)
+	}
+}
+
+func TestListDevURLsSort(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "url-1", Port: 8080, Access: "PRIVATE", Name: "web"},
+		coder.DevURL{ID: "url-2", Port: 3000, Access: "PUBLIC", Name: "api"},
+		coder.DevURL{ID: "url-3", Port: 5000, Access: "ORG", Name: "zeta"},
+	)
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{sort: "", want: []string{"url-2", "url-3", "url-1"}},
+		{sort: "port", want: []string{"url-2", "url-3", "url-1"}},
+		{sort: "name", want: []string{"url-2", "url-1", "url-3"}},
+		{sort: "access", want: []string{"url-3", "url-1", "url-2"}},
+	}
+	for _, test := range tests {
+		args := []string{"urls", "ls", "env1", "-o", "json"}
+		if test.sort != "" {
+			args = append(args, "--sort", test.sort)
+		}
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, args...)
+		})
+		assert.Success(t, "list devurls", err)
+
+		var devURLs []coder.DevURL
+		assert.Success(t, "unmarshal devurls", json.Unmarshal([]byte(output), &devURLs))
+		ids := make([]string, 0, len(devURLs))
+		for _, url := range devURLs {
+			ids = append(ids, url.ID)
+		}
+		assert.Equal(t, "order by "+test.sort, test.want, ids)
+	}
+
+	err := runCmd(t, "urls", "ls", "env1", "--sort", "url")
+	assert.Error(t, "invalid sort key", err)
+}
+
+func TestListDevURLsFailOnEmpty(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-1", Port: 8080, Access: "PRIVATE"})
+
+	for _, output := range []string{humanOutput, jsonOutput} {
+		var err error
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "ls", "env1", "--access", "public", "-o", output)
+		})
+		assert.Success(t, "empty listing succeeds by default", err)
+
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "ls", "env1", "--access", "public", "-o", output, "--fail-on-empty")
+		})
+		assert.Error(t, "empty listing fails", err)
+
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "ls", "env1", "-o", output, "--fail-on-empty")
+		})
+		assert.Success(t, "non-empty listing succeeds", err)
+	}
+}
+
+func TestListDevURLsEmptyStreams(t *testing.T) {
+	newFakeCemanager(t)
+
+	for _, args := range [][]string{
+		{"urls", "ls", "env1"},
+		{"urls", "ls", "env1", "-o", "wide"},
+		{"urls", "ls", "--all"},
+	} {
+		var (
+			err    error
+			stdout string
+		)
+		stderr := captureStderr(t, func() {
+			stdout = captureStdout(t, func() {
+				err = runCmd(t, args...)
+			})
+		})
+		assert.Success(t, "list devurls", err)
+		assert.Equal(t, "empty stdout", "", stdout)
+		assert.True(t, "empty-state message on stderr", strings.Contains(stderr, "no devURLs found"))
+	}
+}
+
+func TestDevURLsDryRun(t *testing.T) {
+	existing := coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE", Name: "web", Scheme: "http"}
+
+	t.Run("create", func(t *testing.T) {
+		fake := newFakeCemanager(t)
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "3000", "--name", "api", "--dry-run")
+		})
+		assert.Success(t, "create devurl", err)
+		assert.Equal(t, "dry run", "POST env_id="+fakeEnvID+" port=3000 access=PRIVATE name=\"api\" scheme=http\n", output)
+		assert.Equal(t, "requests", 0, len(fake.Requests()))
+	})
+
+	t.Run("update json", func(t *testing.T) {
+		fake := newFakeCemanager(t, existing)
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "8080", "--name", "web", "--access", "org", "--dry-run", "-o", "json")
+		})
+		assert.Success(t, "update devurl", err)
+
+		var run devURLDryRun
+		assert.Success(t, "unmarshal dry run", json.Unmarshal([]byte(output), &run))
+		assert.Equal(t, "dry run", devURLDryRun{
+			Method:   http.MethodPut,
+			EnvID:    fakeEnvID,
+			DevURLID: "url-id",
+			Request:  &coder.CreateDevURLReq{EnvID: fakeEnvID, Port: 8080, Access: "ORG", Name: "web", Scheme: "http"},
+		}, run)
+		assert.Equal(t, "requests", 0, len(fake.Requests()))
+	})
+
+	t.Run("rm all", func(t *testing.T) {
+		fake := newFakeCemanager(t, existing, coder.DevURL{ID: "other-id", Port: 3000})
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "rm", "env1", "--all", "--dry-run")
+		})
+		assert.Success(t, "remove devurls", err)
+		assert.Equal(t, "dry run", "DELETE env_id="+fakeEnvID+" url_id=url-id\nDELETE env_id="+fakeEnvID+" url_id=other-id\n", output)
+		assert.Equal(t, "requests", 0, len(fake.Requests()))
+	})
+}
+
+func TestApplyDevURLs(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "devurls.yaml")
+	err := ioutil.WriteFile(manifest, []byte(`
+- port: 8080
+  name: web
+  access: org
+- port: 3000
+  name: api
+- port: 5000
+  name: admin
+  scheme: https
+`), 0600)
+	assert.Success(t, "write manifest", err)
+
+	devURLs := []coder.DevURL{
+		{ID: "web-id", Port: 8080, Access: "PRIVATE", Name: "web", Scheme: "http"},
+		{ID: "api-id", Port: 3000, Access: "PRIVATE", Name: "api", Scheme: "http"},
+		{ID: "old-id", Port: 9090, Access: "PUBLIC", Name: "old", Scheme: "http"},
+	}
+
+	t.Run("without prune", func(t *testing.T) {
+		fake := newFakeCemanager(t, devURLs...)
+		var err error
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "apply", "env1", "-f", manifest, "--yes")
+		})
+		assert.Success(t, "apply manifest", err)
+
+		requests := fake.Requests()
+		assert.Equal(t, "requests", 2, len(requests))
+		assert.Equal(t, "update method", http.MethodPut, requests[0].Method)
+		assert.Equal(t, "update path", "/api/private/environments/"+fakeEnvID+"/devurls/web-id", requests[0].Path)
+		assert.Equal(t, "updated access", "ORG", requests[0].Body.Access)
+		assert.Equal(t, "create method", http.MethodPost, requests[1].Method)
+		assert.Equal(t, "created port", 5000, requests[1].Body.Port)
+		assert.Equal(t, "created scheme", "https", requests[1].Body.Scheme)
+	})
+
+	t.Run("with prune", func(t *testing.T) {
+		fake := newFakeCemanager(t, devURLs...)
+		var err error
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "apply", "env1", "-f", manifest, "--prune", "--yes")
+		})
+		assert.Success(t, "apply manifest", err)
+
+		requests := fake.Requests()
+		assert.Equal(t, "requests", 3, len(requests))
+		assert.Equal(t, "delete method", http.MethodDelete, requests[2].Method)
+		assert.Equal(t, "delete path", "/api/private/environments/"+fakeEnvID+"/devurls/old-id", requests[2].Path)
+
+		err = runCmd(t, "urls", "apply", "env1", "-f", manifest, "--prune", "--yes")
+		assert.Success(t, "apply manifest again", err)
+		assert.Equal(t, "no more requests", 3, len(fake.Requests()))
+	})
+
+	t.Run("plan", func(t *testing.T) {
+		fake := newFakeCemanager(t, devURLs...)
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "apply", "env1", "-f", manifest, "--prune", "--plan", "-o", "json")
+		})
+		assert.Success(t, "plan manifest", err)
+		assert.Equal(t, "no requests", 0, len(fake.Requests()))
+
+		var actions []plannedAction
+		assert.Success(t, "unmarshal plan", json.Unmarshal([]byte(output), &actions))
+		assert.Equal(t, "plan", []plannedAction{
+			{Action: devURLUpdate, Port: 8080, Name: "web", Access: "ORG", Scheme: "http"},
+			{Action: devURLCreate, Port: 5000, Name: "admin", Access: "PRIVATE", Scheme: "https"},
+			{Action: devURLDelete, Port: 9090, Name: "old", Access: "PUBLIC", Scheme: "http"},
+		}, actions)
+
+		output = captureStdout(t, func() {
+			err = runCmd(t, "urls", "apply", "env1", "-f", manifest)
+		})
+		assert.Error(t, "apply without confirmation", err)
+		assert.True(t, "shows the plan table", strings.Contains(output, "Action"))
+		assert.Equal(t, "no requests", 0, len(fake.Requests()))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		fake := newFakeCemanager(t, devURLs...)
+		invalid := filepath.Join(t.TempDir(), "devurls.json")
+		err := ioutil.WriteFile(invalid, []byte(`[{"port": 8080}, {"port": 8080}]`), 0600)
+		assert.Success(t, "write manifest", err)
+
+		err = runCmd(t, "urls", "apply", "env1", "-f", invalid)
+		assert.Error(t, "apply manifest", err)
+		assert.Equal(t, "requests", 0, len(fake.Requests()))
+	})
+}
+
+func TestDevURLsSingleEnvLookup(t *testing.T) {
+	existing := coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE", Name: "web", Scheme: "http"}
+	tests := [][]string{
+		{"urls", "create", "env1", "3000", "--name", "api"},
+		{"urls", "rm", "env1", "8080"},
+		{"urls", "rm", "env1", "--all", "--yes"},
+	}
+	for _, args := range tests {
+		fake := newFakeCemanager(t, existing)
+		var err error
+		captureStdout(t, func() {
+			err = runCmd(t, args...)
+		})
+		assert.Success(t, strings.Join(args, " "), err)
+		assert.Equal(t, "environment lookups of "+strings.Join(args, " "), int32(1), atomic.LoadInt32(&fake.envLookups))
+	}
+}
+
+func TestWithAPIRetries(t *testing.T) {
+	apiRetryBackoff = time.Millisecond
+	defer func() { apiRetryBackoff = 500 * time.Millisecond }()
+
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+		attempts int32
+	}{
+		{name: "transient", statuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}, attempts: 3},
+		{name: "client error", statuses: []int{http.StatusNotFound, http.StatusOK}, wantErr: true, attempts: 1},
+		{name: "exhausted", statuses: []int{500, 500, 500, 500, 500}, wantErr: true, attempts: int32(defaultAPIRetries + 1)},
+	}
+	for _, test := range tests {
+		var attempts int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := test.statuses[atomic.AddInt32(&attempts, 1)-1]
+			w.WriteHeader(status)
+			if status == http.StatusOK {
+				_, _ = w.Write([]byte("[]")) // Best effort.
+			}
+		}))
+
+		u, err := url.Parse(srv.URL)
+		assert.Success(t, "parse test server url", err)
+		client := &coder.Client{BaseURL: u, Token: "fake-session-token"}
+
+		err = withAPIRetries(context.Background(), func(ctx context.Context) error {
+			_, err := client.DevURLs(ctx, fakeEnvID)
+			return err
+		})
+		srv.Close()
+
+		assert.Equal(t, test.name+" failed", test.wantErr, err != nil)
+		assert.Equal(t, test.name+" attempts", test.attempts, atomic.LoadInt32(&attempts))
+	}
+}
+
+func TestSummarizeDevURLs(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "url-1", Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "url-2", Port: 3000, Access: "PUBLIC"},
+		coder.DevURL{ID: "url-3", Port: 5000, Access: "PUBLIC"},
+	)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "summary", "env1", "-o", "json")
+	})
+	assert.Success(t, "summarize devurls", err)
+	var counts map[string]int
+	assert.Success(t, "unmarshal summary", json.Unmarshal([]byte(output), &counts))
+	assert.Equal(t, "counts", map[string]int{"PRIVATE": 1, "ORG": 0, "AUTHED": 0, "PUBLIC": 2}, counts)
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "urls", "summary", "--all")
+	})
+	assert.Success(t, "summarize devurls", err)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Equal(t, "table rows", 5, len(lines))
+	assert.Equal(t, "public row", []string{"PUBLIC", "2"}, strings.Fields(lines[4])[:2])
+}
+
+func TestCreatePublicDevURL(t *testing.T) {
+	fake := newFakeCemanager(t)
+
+	// Tests run without a terminal, so public devurls require --yes.
+	err := runCmd(t, "urls", "create", "env1", "8080", "--access", "public")
+	assert.Error(t, "create public devurl", err)
+	assert.Equal(t, "requests", 0, len(fake.Requests()))
+
+	captureStdout(t, func() {
+		err = runCmd(t, "urls", "create", "env1", "8080", "--access", "public", "--yes")
+	})
+	assert.Success(t, "create public devurl", err)
+	assert.Equal(t, "requests", 1, len(fake.Requests()))
+}
+
+func TestDevURLsOtherUser(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", Port: 8080, Access: "PRIVATE"})
+	defer func() { devURLUser = coder.Me }()
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "other-env", "--user", fakeOtherUserID, "-o", "json")
+	})
+	assert.Success(t, "list devurls of other user", err)
+	assert.True(t, "lists devurls", strings.Contains(output, "url-id"))
+
+	err = runCmd(t, "urls", "ls", "other-env", "--user", "other@coder.com")
+	assert.Error(t, "list devurls without permissions", err)
+	assert.True(t, "authorization error "+err.Error(), strings.Contains(err.Error(), "not authorized"))
+}
+
+func TestRenameDevURL(t *testing.T) {
+	fake := newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PUBLIC", Name: "web", Scheme: "https"})
+
+	err := runCmd(t, "urls", "rename", "env1", "8080", "frontend")
+	assert.Success(t, "rename devurl", err)
+	requests := fake.Requests()
+	assert.Equal(t, "requests", 1, len(requests))
+	assert.Equal(t, "method", http.MethodPut, requests[0].Method)
+	assert.Equal(t, "request", coder.CreateDevURLReq{EnvID: fakeEnvID, Port: 8080, Access: "PUBLIC", Name: "frontend", Scheme: "https"}, requests[0].Body)
+
+	err = runCmd(t, "urls", "rename", "env1", "8080", "1invalid")
+	assert.Error(t, "rename with invalid name", err)
+	err = runCmd(t, "urls", "rename", "env1", "9090", "api")
+	assert.Error(t, "rename missing devurl", err)
+	assert.Equal(t, "requests", 1, len(fake.Requests()))
+}
+
+func TestCreateDevURLsFromPortSpecs(t *testing.T) {
+	fake := newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "9090.coder.com", Port: 9090, Access: "AUTHED", Name: "admin", Scheme: "http"})
+
+	err := runCmd(t, "urls", "create", "env1", "--port", "8080:public:web", "--port", "9090::admin", "--port", "3000", "--yes")
+	assert.Success(t, "create devurls", err)
+
+	requests := fake.Requests()
+	assert.Equal(t, "requests", 2, len(requests))
+	sort.Slice(requests, func(i, j int) bool { return requests[i].Body.Port < requests[j].Body.Port })
+	assert.Equal(t, "unnamed private devurl", coder.CreateDevURLReq{EnvID: fakeEnvID, Port: 3000, Access: "PRIVATE", Scheme: "http"}, requests[0].Body)
+	assert.Equal(t, "public devurl", coder.CreateDevURLReq{EnvID: fakeEnvID, Port: 8080, Access: "PUBLIC", Name: "web", Scheme: "http"}, requests[1].Body)
+
+	for _, spec := range []string{"8080:public:web:extra", "0:private", "8080:secret", "8080:org:1web"} {
+		err := runCmd(t, "urls", "create", "env1", "--port", spec)
+		assert.Error(t, "invalid --port "+spec, err)
+	}
+	assert.Equal(t, "requests", 2, len(fake.Requests()))
+}
+
+func TestListDevURLsTemplate(t *testing.T) {
+	fake := newFakeCemanager(t,
+		coder.DevURL{ID: "url-1", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "url-2", URL: "3000.coder.com", Port: 3000, Access: "ORG"},
+	)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "template", "--template", "{{.Port}} {{.URL}}")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.Equal(t, "output", "3000 3000.coder.com\n8080 8080.coder.com\n", output)
+
+	lookups := atomic.LoadInt32(&fake.envLookups)
+	err = runCmd(t, "urls", "ls", "env1", "-o", "template", "--template", "{{.Port")
+	assert.Error(t, "invalid template", err)
+	err = runCmd(t, "urls", "ls", "env1", "-o", "template")
+	assert.Error(t, "missing template", err)
+	assert.Equal(t, "no api calls", lookups, atomic.LoadInt32(&fake.envLookups))
+}
+
+func TestDevURLsEnvPrefix(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"})
+
+	err := runCmd(t, "urls", "get", "env", "8080")
+	assert.Error(t, "prefix requires --exact=false", err)
+
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "get", "env", "8080", "--exact=false", "-o", "json")
+	})
+	assert.Success(t, "get devurl by env prefix", err)
+	assert.True(t, "found devurl", strings.Contains(output, `"url-id"`))
+
+	err = runCmd(t, "urls", "get", "other", "8080", "--exact=false")
+	assert.Error(t, "unknown prefix", err)
+}
+
+func TestDevURLsJSONErrors(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"})
+
+	for _, args := range [][]string{
+		{"urls", "get", "env1", "9090", "-o", "json"},
+		{"urls", "ls", "missing-env", "-o", "json-lines"},
+		{"urls", "ls", "-o", "json"},
+	} {
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, args...)
+		})
+		assert.Error(t, strings.Join(args, " "), err)
+
+		var jsonErr map[string]string
+		assert.Success(t, "unmarshal error", json.Unmarshal([]byte(output), &jsonErr))
+		assert.Equal(t, "error object", map[string]string{"error": err.Error()}, jsonErr)
+	}
+
+	output := captureStdout(t, func() {
+		err := runCmd(t, "urls", "get", "env1", "9090")
+		assert.Error(t, "get missing devurl", err)
+	})
+	assert.Equal(t, "no error object with human output", "", output)
+}
+
+func TestCreateDevURLDefaultAccessEnv(t *testing.T) {
+	fake := newFakeCemanager(t)
+	setFakeEnv(t, "CODER_DEVURL_DEFAULT_ACCESS", "org")
+
+	captureStdout(t, func() {
+		err := runCmd(t, "urls", "create", "env1", "8080")
+		assert.Success(t, "create devurl", err)
+		err = runCmd(t, "urls", "create", "env1", "9090", "--access", "private")
+		assert.Success(t, "create devurl", err)
+	})
+	requests := fake.Requests()
+	assert.Equal(t, "requests", 2, len(requests))
+	assert.Equal(t, "default access", "ORG", requests[0].Body.Access)
+	assert.Equal(t, "explicit access", "PRIVATE", requests[1].Body.Access)
+
+	setFakeEnv(t, "CODER_DEVURL_DEFAULT_ACCESS", "everyone")
+	err := runCmd(t, "urls", "create", "env1", "3000", "--access", "private")
+	assert.Error(t, "invalid default access", err)
+	assert.Equal(t, "requests", 2, len(fake.Requests()))
+}
+
+func TestCreateDevURLDefaultAccessEnvPublic(t *testing.T) {
+	fake := newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "9090.coder.com", Port: 9090, Access: "PUBLIC", Scheme: "http"})
+	setFakeEnv(t, "CODER_DEVURL_DEFAULT_ACCESS", "public")
+
+	var err error
+	captureStdout(t, func() {
+		err = runCmd(t, "urls", "create", "env1", "8080")
+	})
+	assert.ErrorContains(t, "public default access needs confirmation", err, "refusing to create a public devurl without confirmation")
+	assert.Equal(t, "no requests", 0, len(fake.Requests()))
+
+	captureStdout(t, func() {
+		err = runCmd(t, "urls", "create", "env1", "8080", "--yes")
+		assert.Success(t, "confirmed public devurl", err)
+		// Updating a devurl which is already public doesn't need confirmation.
+		err = runCmd(t, "urls", "create", "env1", "9090", "--name", "api")
+		assert.Success(t, "update public devurl", err)
+	})
+	requests := fake.Requests()
+	assert.Equal(t, "requests", 2, len(requests))
+	assert.Equal(t, "default access", "PUBLIC", requests[0].Body.Access)
+}
+
+func TestDevURLsEnvID(t *testing.T) {
+	fake := newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"})
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "get", "--env-id", fakeEnvID, "8080", "-o", "json")
+	})
+	assert.Success(t, "get devurl by env id", err)
+	assert.True(t, "found devurl", strings.Contains(output, `"url-id"`))
+
+	err = runCmd(t, "urls", "rm", "--env-id", fakeEnvID, "8080")
+	assert.Success(t, "remove devurl by env id", err)
+	assert.Equal(t, "requests", 1, len(fake.Requests()))
+	assert.Equal(t, "no env lookups", int32(0), atomic.LoadInt32(&fake.envLookups))
+
+	err = runCmd(t, "urls", "get", "env1", "8080", "--env-id", fakeEnvID)
+	assert.Error(t, "env name and id", err)
+}
+
+func TestListDevURLsCount(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "url-1", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "url-2", URL: "3000.coder.com", Port: 3000, Access: "ORG"},
+	)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"urls", "ls", "env1", "--count"}, "2\n"},
+		{[]string{"urls", "ls", "env1", "--count", "--access", "org", "-o", "json"}, `{"count":1}` + "\n"},
+		{[]string{"urls", "ls", "env1", "--count", "--access", "public"}, "0\n"},
+	}
+	for _, test := range tests {
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, test.args...)
+		})
+		assert.Success(t, strings.Join(test.args, " "), err)
+		assert.Equal(t, strings.Join(test.args, " "), test.want, output)
+	}
+
+	err := runCmd(t, "urls", "ls", "env1", "--count", "-o", "yaml")
+	assert.Error(t, "count with yaml output", err)
+}
+
+func TestListDevURLsNoHeaders(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"})
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "--no-headers")
+	})
+	assert.Success(t, "list devurls", err)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Equal(t, "table rows", 1, len(lines))
+	assert.True(t, "first line is data", strings.HasPrefix(lines[0], "8080.coder.com"))
+}
+
+func TestListDevURLsCSV(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE", Name: "web"})
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "csv")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.Equal(t, "csv", "URL,Port,Access\n8080.coder.com,8080,PRIVATE\n", output)
+}
+
+func TestCreateDevURLSchemePortWarning(t *testing.T) {
+	fake := newFakeCemanager(t)
+
+	tests := []struct {
+		args []string
+		warn bool
+	}{
+		{[]string{"urls", "create", "env1", "80", "--scheme", "https"}, true},
+		{[]string{"urls", "create", "env1", "443", "--scheme", "http"}, true},
+		{[]string{"urls", "create", "env1", "443", "--scheme", "https"}, false},
+		{[]string{"urls", "create", "env1", "80", "--scheme", "https", "--no-warn"}, false},
+		{[]string{"urls", "create", "env1", "8080", "--scheme", "https"}, false},
+	}
+	for _, test := range tests {
+		var err error
+		stderr := captureStderr(t, func() {
+			captureStdout(t, func() {
+				err = runCmd(t, test.args...)
+			})
+		})
+		assert.Success(t, strings.Join(test.args, " "), err)
+		assert.Equal(t, strings.Join(test.args, " ")+" warns", test.warn, strings.Contains(stderr, "usually serves"))
+	}
+	assert.Equal(t, "warnings don't block", len(tests), len(fake.Requests()))
+}
+
+func TestDevURLsOrg(t *testing.T) {
+	fake := newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"})
+	fake.envs = []coder.Environment{{ID: fakeEnvID, Name: "shared", OrganizationID: fakeOrgID}}
+	fake.otherOrgEnvs = []coder.Environment{{ID: "other-env-id", Name: "shared", OrganizationID: fakeOtherOrgID}}
+
+	for _, args := range [][]string{
+		{"urls", "get", "shared", "8080"},
+		{"urls", "get", "sha", "8080", "--exact=false"},
+	} {
+		err := runCmd(t, args...)
+		assert.Error(t, "ambiguous env", err)
+		var cliErr clog.CLIError
+		assert.True(t, "cli error", xerrors.As(err, &cliErr))
+		assert.True(t, "lists the orgs", strings.Contains(strings.Join(cliErr.Lines, "\n"), `["default" "other"]`))
+	}
+
+	for _, org := range []string{"default", fakeOrgID} {
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "get", "shared", "8080", "--org", org, "-o", "json")
+		})
+		assert.Success(t, "get devurl in org "+org, err)
+		assert.True(t, "found devurl", strings.Contains(output, `"url-id"`))
+	}
+
+	err := runCmd(t, "urls", "get", "shared", "8080", "--org", "missing")
+	assert.Error(t, "unknown org", err)
+}
+
+func TestDevURLsDefaultEnv(t *testing.T) {
+	fake := newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"})
+
+	err := runCmd(t, "urls", "get", "8080")
+	assert.Error(t, "no default env", err)
+
+	setFakeEnv(t, "CODER_DEFAULT_ENV", "env1")
+	var output string
+	stderr := captureStderr(t, func() {
+		output = captureStdout(t, func() {
+			err = runCmd(t, "urls", "get", "8080", "-o", "json")
+		})
+	})
+	assert.Success(t, "get devurl of default env", err)
+	assert.True(t, "found devurl", strings.Contains(output, `"url-id"`))
+	assert.True(t, "logs the default env", strings.Contains(stderr, `using the default environment "env1"`))
+
+	captureStdout(t, func() {
+		err = runCmd(t, "urls", "create", "env1", "9090")
+	})
+	assert.Success(t, "explicit env name", err)
+	assert.Equal(t, "requests", 1, len(fake.Requests()))
+
+	setFakeEnv(t, "CODER_DEFAULT_ENV", "missing-env")
+	err = runCmd(t, "urls", "ls")
+	assert.Error(t, "unknown default env", err)
+}
+
+func TestDevURLsStoppedEnv(t *testing.T) {
+	fake := newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"})
+	fake.envs[0].LatestStat.ContainerStatus = coder.EnvironmentOff
+
+	for _, args := range [][]string{
+		{"urls", "ls", "env1"},
+		{"urls", "create", "env1", "9090"},
+		{"urls", "rm", "env1", "8080"},
+	} {
+		err := runCmd(t, args...)
+		assert.Error(t, strings.Join(args, " "), err)
+		assert.True(t, "stopped env error", strings.Contains(err.Error(), `environment "env1" is stopped`))
+	}
+	assert.Equal(t, "no requests", 0, len(fake.Requests()))
+}
+
+func TestCreateDevURLPortFromProcessFlags(t *testing.T) {
+	fake := newFakeCemanager(t)
+
+	for _, args := range [][]string{
+		{"urls", "create", "env1", "8080", "--first"},
+		{"urls", "create", "env1", "--port-from-process", "node", "--auto-port"},
+		{"urls", "create", "env1", "--port-from-process", "node", "--port", "8080"},
+		{"urls", "create", "env1", "8080", "--port-from-process", "node"},
+	} {
+		err := runCmd(t, args...)
+		assert.Error(t, strings.Join(args, " "), err)
+	}
+	assert.Equal(t, "no requests", 0, len(fake.Requests()))
+}
+
+func TestCreateWildcardDevURL(t *testing.T) {
+	fake := newFakeCemanager(t)
+
+	captureStdout(t, func() {
+		err := runCmd(t, "urls", "create", "env1", "8080", "--wildcard")
+		assert.Success(t, "create wildcard devurl", err)
+		// Updates keep the wildcard setting.
+		err = runCmd(t, "urls", "create", "env1", "8080", "--access", "org")
+		assert.Success(t, "update wildcard devurl", err)
+	})
+	requests := fake.Requests()
+	assert.Equal(t, "requests", 2, len(requests))
+	assert.True(t, "wildcard create", requests[0].Body.Wildcard)
+	assert.True(t, "wildcard kept", requests[1].Body.Wildcard)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.True(t, "wildcard marker", strings.Contains(output, "*.8080.coder.com"))
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "json")
+	})
+	assert.Success(t, "list devurls as json", err)
+	assert.True(t, "no marker in json", strings.Contains(output, `"url":"8080.coder.com"`))
+	assert.True(t, "wildcard field", strings.Contains(output, `"wildcard":true`))
+
+	err = runCmd(t, "urls", "create", "env1", "9090", "--wildcard", "--name", "web")
+	assert.Error(t, "named wildcard devurl", err)
+	assert.Equal(t, "requests", 2, len(fake.Requests()))
+}
+
+func TestDevURLsTimings(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"})
+
+	var err error
+	stderr := captureStderr(t, func() {
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "9090", "--timings")
+		})
+	})
+	assert.Success(t, "create devurl", err)
+	for _, step := range []string{"find environment: ", "list devurls: ", "create devurl: "} {
+		assert.True(t, "reports "+step, strings.Contains(stderr, step))
+	}
+
+	var stdout string
+	stderr = captureStderr(t, func() {
+		stdout = captureStdout(t, func() {
+			err = runCmd(t, "urls", "rm", "env1", "8080", "--timings", "-o", "json")
+		})
+	})
+	assert.Success(t, "remove devurl", err)
+	var deletion devURLDeletion
+	assert.Success(t, "unmarshal deletion", json.Unmarshal([]byte(stdout), &deletion))
+	steps := make([]string, 0, len(deletion.Timings))
+	for _, timing := range deletion.Timings {
+		steps = append(steps, timing.Step)
+	}
+	assert.Equal(t, "steps", []string{"find environment", "list devurls", "delete devurl for port 8080"}, steps)
+	assert.True(t, "timings not reported again", !strings.Contains(stderr, "timings"))
+
+	stderr = captureStderr(t, func() {
+		stdout = captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "8080", "--timings", "-o", "json")
+		})
+	})
+	assert.Success(t, "create devurl", err)
+	var created timedDevURL
+	assert.Success(t, "unmarshal devurl", json.Unmarshal([]byte(stdout), &created))
+	assert.Equal(t, "devurl port", 8080, created.Port)
+	steps = steps[:0]
+	for _, timing := range created.Timings {
+		steps = append(steps, timing.Step)
+	}
+	assert.True(t, "create timing", strings.Contains(strings.Join(steps, ","), "create devurl"))
+	assert.True(t, "timings not reported again", !strings.Contains(stderr, "timings"))
+
+	stderr = captureStderr(t, func() {
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "ls", "env1")
+		})
+	})
+	assert.Success(t, "list devurls", err)
+	assert.True(t, "no timings by default", !strings.Contains(stderr, "timings"))
+}
+
+func TestCreateDevURLQuiet(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"})
+
+	for _, scene := range []struct {
+		flag string
+		logs bool
+	}{
+		{"--quiet", false},
+		{"--verbose", true},
+		{"--timeout=30s", true},
+	} {
+		var (
+			err    error
+			output string
+		)
+		stderr := captureStderr(t, func() {
+			output = captureStdout(t, func() {
+				err = runCmd(t, "urls", "create", "env1", "8080", scene.flag)
+			})
+		})
+		assert.Success(t, "update devurl "+scene.flag, err)
+		assert.Equal(t, "prints the url "+scene.flag, "http://8080.coder.com\n", output)
+		assert.Equal(t, "logs the update "+scene.flag, scene.logs, strings.Contains(stderr, "updated existing devurl for port 8080"))
+	}
+
+	err := runCmd(t, "urls", "create", "env1", "8080", "--quiet", "--verbose")
+	assert.Error(t, "quiet and verbose", err)
+}
+
+func TestCreateDevURLDuplicateName(t *testing.T) {
+	fake := newFakeCemanager(t, coder.DevURL{ID: "web-id", URL: "web.coder.com", Port: 8080, Access: "PRIVATE", Name: "web"})
+
+	for _, args := range [][]string{
+		{"urls", "create", "env1", "9090", "--name", "web"},
+		{"urls", "create", "env1", "auto", "--name", "web"},
+		{"urls", "create", "env1", "--port", "9090:private:web"},
+		{"urls", "create", "env1", "--port", "9090::api", "--port", "3000::api"},
+	} {
+		err := runCmd(t, args...)
+		assert.Error(t, strings.Join(args, " "), err)
+	}
+	assert.Equal(t, "no requests", 0, len(fake.Requests()))
+
+	captureStdout(t, func() {
+		err := runCmd(t, "urls", "create", "env1", "8080", "--name", "web")
+		assert.Success(t, "same name on the same port", err)
+		err = runCmd(t, "urls", "create", "env1", "3000")
+		assert.Success(t, "unnamed devurl", err)
+		err = runCmd(t, "urls", "create", "env1", "9090", "--name", "web", "--allow-duplicate-name")
+		assert.Success(t, "allowed duplicate name", err)
+	})
+	assert.Equal(t, "requests", 3, len(fake.Requests()))
+}
+
+func TestCreateDevURLRetriedOnce(t *testing.T) {
+	apiRetryBackoff = time.Millisecond
+	defer func() { apiRetryBackoff = 500 * time.Millisecond }()
+
+	fake := newFakeCemanager(t)
+	fake.lostCreates = 1
+
+	var err error
+	captureStderr(t, func() {
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "8080", "--name", "web")
+		})
+	})
+	assert.Success(t, "create devurl", err)
+
+	requests := fake.Requests()
+	assert.Equal(t, "requests", 2, len(requests))
+	assert.Equal(t, "first attempt", http.MethodPost, requests[0].Method)
+	assert.Equal(t, "retry updates the created devurl", http.MethodPut, requests[1].Method)
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.Equal(t, "single devurl", 1, len(fake.devURLs))
+}
+
+func TestCreateDevURLAutoPortRetriedOnce(t *testing.T) {
+	apiRetryBackoff = time.Millisecond
+	defer func() { apiRetryBackoff = 500 * time.Millisecond }()
+
+	t.Run("named", func(t *testing.T) {
+		fake := newFakeCemanager(t)
+		fake.lostCreates = 1
+
+		var (
+			err    error
+			output string
+		)
+		captureStderr(t, func() {
+			output = captureStdout(t, func() {
+				err = runCmd(t, "urls", "create", "env1", "auto", "--name", "preview")
+			})
+		})
+		assert.Success(t, "create devurl", err)
+		assert.Equal(t, "printed url", fmt.Sprintf("http://%d.coder.com\n", fakeAutoPort), output)
+
+		assert.Equal(t, "retry adopts the created devurl", 1, len(fake.Requests()))
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		assert.Equal(t, "single devurl", 1, len(fake.devURLs))
+	})
+
+	t.Run("unnamed", func(t *testing.T) {
+		fake := newFakeCemanager(t)
+		fake.lostCreates = 1
+
+		var err error
+		captureStderr(t, func() {
+			captureStdout(t, func() {
+				err = runCmd(t, "urls", "create", "env1", "auto")
+			})
+		})
+		assert.Error(t, "lost creation", err)
+		assert.Equal(t, "not retried", 1, len(fake.Requests()))
+	})
+}
+
+func TestDeleteDevURLRetriedOnce(t *testing.T) {
+	apiRetryBackoff = time.Millisecond
+	defer func() { apiRetryBackoff = 500 * time.Millisecond }()
+
+	manifest := filepath.Join(t.TempDir(), "devurls.yaml")
+	assert.Success(t, "write manifest", ioutil.WriteFile(manifest, []byte("[]"), 0600))
+
+	for name, args := range map[string][]string{
+		"rm":          {"urls", "rm", "env1", "8080"},
+		"rm all":      {"urls", "rm", "env1", "--all", "--yes"},
+		"apply prune": {"urls", "apply", "env1", "-f", manifest, "--prune", "--yes"},
+	} {
+		args := args
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"})
+			fake.lostDeletes = 1
+
+			var err error
+			captureStderr(t, func() {
+				captureStdout(t, func() {
+					err = runCmd(t, args...)
+				})
+			})
+			assert.Success(t, "delete devurl", err)
+
+			requests := fake.Requests()
+			assert.Equal(t, "requests", 2, len(requests))
+			assert.Equal(t, "retried delete", http.MethodDelete, requests[1].Method)
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		newFakeCemanager(t)
+		var (
+			client *coder.Client
+			err    error
+		)
+		captureStderr(t, func() {
+			client, err = newClient(context.Background())
+		})
+		assert.Success(t, "new client", err)
+		captureStderr(t, func() {
+			err = deleteDevURLWithRetries(context.Background(), client, fakeEnvID, "url-id")
+		})
+		assert.Error(t, "first attempt not found", err)
+	})
+}
+
+func TestListDevURLsOutputFile(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"})
+	path := filepath.Join(t.TempDir(), "reports", "devurls.csv")
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "csv", "--output-file", path)
+	})
+	assert.Success(t, "list devurls to file", err)
+	assert.Equal(t, "nothing on stdout", "", output)
+	written, err := ioutil.ReadFile(path)
+	assert.Success(t, "read output file", err)
+	assert.Equal(t, "output file", "URL,Port,Access\n8080.coder.com,8080,PRIVATE\n", string(written))
+
+	err = runCmd(t, "urls", "ls", "env1", "-o", "json", "--output-file", path)
+	assert.Error(t, "existing output file", err)
+
+	err = runCmd(t, "urls", "ls", "env1", "-o", "json", "--output-file", path, "--force")
+	assert.Success(t, "overwrite output file", err)
+	written, err = ioutil.ReadFile(path)
+	assert.Success(t, "read output file", err)
+	assert.True(t, "json output file", strings.HasPrefix(string(written), `[{"id":"url-id"`))
+
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	err = runCmd(t, "urls", "ls", "missing-env", "--output-file", missing)
+	assert.Error(t, "unknown env", err)
+	_, err = os.Stat(missing)
+	assert.True(t, "no output file on failure", os.IsNotExist(err))
+
+	err = runCmd(t, "urls", "ls", "env1", "--force")
+	assert.Error(t, "force without output file", err)
+}
+
+func TestDevURLsEnvNotFound(t *testing.T) {
+	fake := newFakeCemanager(t)
+
+	envNotFoundLines := func(args ...string) string {
+		err := runCmd(t, args...)
+		assert.Error(t, strings.Join(args, " "), err)
+		var cliErr clog.CLIError
+		assert.True(t, "cli error", xerrors.As(err, &cliErr))
+		return strings.Join(cliErr.Lines, "\n")
+	}
+
+	lines := envNotFoundLines("urls", "ls", "emv1")
+	assert.True(t, "lists the envs", strings.Contains(lines, `your environments are ["env1"]`))
+	assert.True(t, "suggests the closest env", strings.Contains(lines, `did you mean "env1"?`))
+
+	lines = envNotFoundLines("urls", "ls", "frontend")
+	assert.True(t, "no suggestion", !strings.Contains(lines, "did you mean"))
+
+	fake.mu.Lock()
+	for i := 2; i <= 15; i++ {
+		fake.envs = append(fake.envs, coder.Environment{ID: fmt.Sprintf("env-%d", i), Name: fmt.Sprintf("env%d", i), OrganizationID: fakeOrgID})
+	}
+	fake.mu.Unlock()
+	lines = envNotFoundLines("urls", "ls", "missing-env")
+	assert.True(t, "caps the listed envs", strings.Contains(lines, "and 5 more"))
+	assert.True(t, "omits the last envs", !strings.Contains(lines, `"env15"`))
+}
+
+func TestCreateDevURLDefaultSchemeEnv(t *testing.T) {
+	fake := newFakeCemanager(t)
+
+	setFakeEnv(t, "CODER_DEVURL_DEFAULT_SCHEME", "HTTPS")
+	captureStdout(t, func() {
+		err := runCmd(t, "urls", "create", "env1", "8443")
+		assert.Success(t, "create devurl", err)
+		err = runCmd(t, "urls", "create", "env1", "8080", "--scheme", "http")
+		assert.Success(t, "create devurl", err)
+	})
+
+	setFakeEnv(t, "CODER_DEVURL_DEFAULT_SCHEME", "ftp")
+	var err error
+	stderr := captureStderr(t, func() {
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "3000")
+		})
+	})
+	assert.Success(t, "create devurl with invalid default scheme", err)
+	assert.True(t, "warns about the default scheme", strings.Contains(stderr, `invalid CODER_DEVURL_DEFAULT_SCHEME "ftp"`))
+
+	requests := fake.Requests()
+	assert.Equal(t, "requests", 3, len(requests))
+	assert.Equal(t, "default scheme", "https", requests[0].Body.Scheme)
+	assert.Equal(t, "explicit scheme", "http", requests[1].Body.Scheme)
+	assert.Equal(t, "fallback scheme", "http", requests[2].Body.Scheme)
+}
+
+func TestDevURLsSchema(t *testing.T) {
+	var err error
+	stdout := captureStdout(t, func() {
+		err = runCmd(t, "urls", "schema")
+	})
+	assert.Success(t, "urls schema", err)
+
+	var schema jsonSchema
+	assert.Success(t, "decode schema", json.Unmarshal([]byte(stdout), &schema))
+	assert.Equal(t, "schema type", "array", schema.Type)
+	assert.True(t, "schema items", schema.Items != nil)
+
+	// Every field of the json output must be described by the schema.
+	now, reachable, status := time.Now(), true, http.StatusOK
+	raw, err := json.Marshal(devURLRecord{
+		Environment: "env1",
+		DevURL:      coder.DevURL{Wildcard: true, CreatedAt: &now, UpdatedAt: &now},
+		Description: "desc",
+		Reachable:   &reachable,
+		Status:      &status,
+	})
+	assert.Success(t, "encode record", err)
+	var fields map[string]interface{}
+	assert.Success(t, "decode record", json.Unmarshal(raw, &fields))
+	assert.Equal(t, "property count", len(fields), len(schema.Items.Properties))
+	for name := range fields {
+		assert.True(t, "property "+name, schema.Items.Properties[name] != nil)
+	}
+	assert.Equal(t, "port type", "integer", schema.Items.Properties["port"].Type)
+	assert.Equal(t, "wildcard type", "boolean", schema.Items.Properties["wildcard"].Type)
+	assert.Equal(t, "created_at format", "date-time", schema.Items.Properties["created_at"].Format)
+	assert.Equal(t, "required", []string{"id", "url", "port", "access", "name", "scheme"}, schema.Items.Required)
+}
+
+func TestValidateDevURLs(t *testing.T) {
+	dir := t.TempDir()
+	policy := filepath.Join(dir, "policy.yaml")
+	err := ioutil.WriteFile(policy, []byte(`
+access: [private, org]
+name_pattern: "^[a-z]+-svc$"
+disallowed_ports: [22]
+`), 0600)
+	assert.Success(t, "write policy", err)
+
+	t.Run("live", func(t *testing.T) {
+		newFakeCemanager(t,
+			coder.DevURL{ID: "web-id", Port: 8080, Access: "PRIVATE", Name: "web-svc"},
+			coder.DevURL{ID: "pub-id", Port: 9090, Access: "PUBLIC", Name: "pub-svc"},
+			coder.DevURL{ID: "ssh-id", Port: 22, Access: "ORG", Name: "ssh"},
+		)
+		var err error
+		output := captureStdout(t, func() {
+			err = runCmd(t, "urls", "validate", "env1", "--policy", policy, "-o", "json")
+		})
+		assert.Error(t, "violations fail the command", err)
+
+		// The violations are followed by the json error.
+		var violations []devURLViolation
+		assert.Success(t, "decode violations", json.NewDecoder(strings.NewReader(output)).Decode(&violations))
+		assert.Equal(t, "violations", []devURLViolation{
+			{Port: 9090, Name: "pub-svc", Rule: policyAccessRule, Reason: "access level PUBLIC is not one of PRIVATE, ORG"},
+			{Port: 22, Name: "ssh", Rule: policyNameRule, Reason: `name doesn't match "^[a-z]+-svc$"`},
+			{Port: 22, Name: "ssh", Rule: policyPortRule, Reason: "port 22 must not be exposed"},
+		}, violations)
+	})
+
+	t.Run("manifest", func(t *testing.T) {
+		manifest := filepath.Join(dir, "devurls.yaml")
+		err := ioutil.WriteFile(manifest, []byte("- port: 8080\n  name: web-svc\n"), 0600)
+		assert.Success(t, "write manifest", err)
+
+		fake := newFakeCemanager(t)
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "validate", "--policy", policy, "-f", manifest)
+		})
+		assert.Success(t, "compliant manifest", err)
+		assert.Equal(t, "no requests", 0, len(fake.Requests()))
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		invalid := filepath.Join(dir, "invalid.yaml")
+		err := ioutil.WriteFile(invalid, []byte("access: [everyone]\n"), 0600)
+		assert.Success(t, "write policy", err)
+
+		newFakeCemanager(t)
+		err = runCmd(t, "urls", "validate", "env1", "--policy", invalid)
+		assert.Error(t, "invalid access level", err)
+	})
+}
+
+func TestExportImportDevURLs(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "web-id", Port: 8080, Access: "ORG", Name: "web", Scheme: "http"},
+		coder.DevURL{ID: "api-id", Port: 3000, Access: "PRIVATE", Name: "api", Scheme: "https"},
+	)
+	var err error
+	exported := captureStdout(t, func() {
+		err = runCmd(t, "urls", "export", "env1")
+	})
+	assert.Success(t, "export devurls", err)
+	assert.Equal(t, "manifest", `- port: 3000
+  name: api
+  access: private
+  scheme: https
+- port: 8080
+  name: web
+  access: org
+  scheme: http
+`, exported)
+
+	manifest := filepath.Join(t.TempDir(), "devurls.yaml")
+	assert.Success(t, "write manifest", ioutil.WriteFile(manifest, []byte(exported), 0600))
+
+	t.Run("skip existing", func(t *testing.T) {
+		fake := newFakeCemanager(t, coder.DevURL{ID: "old-id", Port: 8080, Access: "PRIVATE", Name: "old", Scheme: "http"})
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "import", "env1", "-f", manifest)
+		})
+		assert.Success(t, "import devurls", err)
+
+		requests := fake.Requests()
+		assert.Equal(t, "requests", 1, len(requests))
+		assert.Equal(t, "create method", http.MethodPost, requests[0].Method)
+		assert.Equal(t, "created port", 3000, requests[0].Body.Port)
+		assert.Equal(t, "remapped env", fakeEnvID, requests[0].Body.EnvID)
+	})
+
+	t.Run("update existing", func(t *testing.T) {
+		fake := newFakeCemanager(t, coder.DevURL{ID: "old-id", Port: 8080, Access: "PRIVATE", Name: "old", Scheme: "http"})
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "import", "env1", "-f", manifest, "--existing", "update")
+		})
+		assert.Success(t, "import devurls", err)
+
+		requests := fake.Requests()
+		assert.Equal(t, "requests", 2, len(requests))
+		assert.Equal(t, "update method", http.MethodPut, requests[1].Method)
+		assert.Equal(t, "updated name", "web", requests[1].Body.Name)
+		assert.Equal(t, "updated access", "ORG", requests[1].Body.Access)
+	})
+
+	err = runCmd(t, "urls", "import", "env1", "-f", manifest, "--existing", "replace")
+	assert.Error(t, "invalid --existing", err)
+}
+
+func TestCreateDevURLAuditLog(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "url-1", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE", Scheme: "http"},
+		coder.DevURL{ID: "url-2", URL: "9090.coder.com", Port: 9090, Access: "PUBLIC", Scheme: "http"},
+	)
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+
+	captureStdout(t, func() {
+		err := runCmd(t, "urls", "create", "env1", "8080", "--access", "public", "--yes", "--audit-log", auditLog)
+		assert.Success(t, "make devurl public", err)
+		err = runCmd(t, "urls", "create", "env1", "9090", "--name", "api", "--audit-log", auditLog)
+		assert.Success(t, "update public devurl", err)
+		err = runCmd(t, "urls", "create", "env1", "3000", "--access", "org", "--audit-log", auditLog)
+		assert.Success(t, "create org devurl", err)
+		err = runCmd(t, "urls", "create", "env1", "--port", "4000:public", "--yes", "--audit-log", auditLog)
+		assert.Success(t, "create public devurl", err)
+	})
+
+	raw, err := ioutil.ReadFile(auditLog)
+	assert.Success(t, "read audit log", err)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	assert.Equal(t, "audit lines", 2, len(lines))
+
+	var entries []devURLAuditEntry
+	for _, line := range lines {
+		var entry devURLAuditEntry
+		assert.Success(t, "decode audit line", json.Unmarshal([]byte(line), &entry))
+		assert.True(t, "timestamp", !entry.Time.IsZero())
+		entries = append(entries, entry)
+	}
+	assert.Equal(t, "updated port", 8080, entries[0].Port)
+	assert.Equal(t, "environment", "env1", entries[0].Environment)
+	assert.Equal(t, "old access", "PRIVATE", entries[0].OldAccess)
+	assert.Equal(t, "new access", "PUBLIC", entries[0].NewAccess)
+	assert.Equal(t, "created port", 4000, entries[1].Port)
+	assert.Equal(t, "created old access", "", entries[1].OldAccess)
+
+	stderr := captureStderr(t, func() {
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "create", "env1", "5000", "--access", "public", "--yes")
+		})
+	})
+	assert.Success(t, "create public devurl", err)
+	assert.True(t, "audits to stderr", strings.Contains(stderr, `"new_access":"PUBLIC"`))
+}
+
+func TestDevURLExamples(t *testing.T) {
+	t.Parallel()
+
+	app := Make()
+	urls, _, err := app.Find([]string{"urls"})
+	assert.Success(t, "find urls command", err)
+	for _, cmd := range append([]*cobra.Command{urls}, urls.Commands()...) {
+		if cmd.Hidden || cmd.Name() == "help" {
+			continue
+		}
+		assert.True(t, cmd.CommandPath()+" has examples", cmd.Example != "")
+		for _, line := range strings.Split(cmd.Example, "\n") {
+			// Check every command of a pipeline, such as urls export piped to urls import.
+			for _, invocation := range strings.Split(line, "|") {
+				fields := strings.Fields(invocation)
+				if len(fields) < 1 || fields[0] != "coder" {
+					continue
+				}
+				example, args, err := app.Find(fields[1:])
+				assert.Success(t, "find command of example "+line, err)
+				for _, arg := range args {
+					if arg == "-" || !strings.HasPrefix(arg, "-") {
+						continue
+					}
+					name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+					var found bool
+					if strings.HasPrefix(arg, "--") {
+						found = example.Flags().Lookup(name) != nil || example.InheritedFlags().Lookup(name) != nil
+					} else {
+						found = example.Flags().ShorthandLookup(name) != nil || example.InheritedFlags().ShorthandLookup(name) != nil
+					}
+					assert.True(t, fmt.Sprintf("flag %s of example %q exists", arg, line), found)
+				}
+			}
+		}
+	}
+}
+
+func TestDiffDevURLs(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "devurls.yaml")
+	err := ioutil.WriteFile(manifest, []byte(`
+- port: 8080
+  name: web
+  access: org
+- port: 3000
+  name: app
+- port: 5000
+  name: same
+`), 0600)
+	assert.Success(t, "write manifest", err)
+
+	t.Run("drift", func(t *testing.T) {
+		fake := newFakeCemanager(t,
+			coder.DevURL{ID: "web-id", Port: 8080, Access: "PUBLIC", Name: "web", Scheme: "http"},
+			coder.DevURL{ID: "same-id", Port: 5000, Access: "PRIVATE", Name: "same", Scheme: "http"},
+			coder.DevURL{ID: "old-id", Port: 9090, Access: "PRIVATE", Name: "old", Scheme: "https"},
+		)
+		var err error
+		stdout := captureStdout(t, func() {
+			err = runCmd(t, "urls", "diff", "env1", "-f", manifest)
+		})
+		assert.Error(t, "drift fails the command", err)
+		assert.Equal(t, "diff", strings.Join([]string{
+			`~ port 8080: access "public" -> "org"`,
+			`+ port 3000: name "app", access "private", scheme "http"`,
+			`- port 9090: name "old", access "private", scheme "https"`,
+		}, "\n")+"\n", stdout)
+
+		stdout = captureStdout(t, func() {
+			err = runCmd(t, "urls", "diff", "env1", "-f", manifest, "-o", "json")
+		})
+		assert.Error(t, "drift fails the command", err)
+		// The drift is followed by the json error.
+		var drifts []devURLDrift
+		assert.Success(t, "decode drift", json.NewDecoder(strings.NewReader(stdout)).Decode(&drifts))
+		assert.Equal(t, "drifts", 3, len(drifts))
+		assert.Equal(t, "changed action", devURLUpdate, drifts[0].Action)
+		assert.Equal(t, "current access", "public", drifts[0].Current.Access)
+		assert.Equal(t, "desired access", "org", drifts[0].Desired.Access)
+		assert.Equal(t, "added action", devURLCreate, drifts[1].Action)
+		assert.True(t, "added has no current", drifts[1].Current == nil)
+		assert.Equal(t, "removed port", 9090, drifts[2].Port)
+		assert.True(t, "removed has no desired", drifts[2].Desired == nil)
+
+		assert.Equal(t, "no requests", 0, len(fake.Requests()))
+	})
+
+	t.Run("no drift", func(t *testing.T) {
+		newFakeCemanager(t,
+			coder.DevURL{ID: "web-id", Port: 8080, Access: "ORG", Name: "web", Scheme: "http"},
+			coder.DevURL{ID: "app-id", Port: 3000, Access: "PRIVATE", Name: "app", Scheme: "http"},
+			coder.DevURL{ID: "same-id", Port: 5000, Access: "PRIVATE", Name: "same", Scheme: "http"},
+		)
+		var err error
+		stdout := captureStdout(t, func() {
+			err = runCmd(t, "urls", "diff", "env1", "-f", manifest)
+		})
+		assert.Success(t, "diff without drift", err)
+		assert.Equal(t, "empty diff", "", stdout)
+	})
+}
+
+func TestFindEnvOwner(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", Port: 8080, Access: "PRIVATE"})
+
+	for _, owner := range []string{"other", "other@coder.com", fakeOtherUserID} {
+		var err error
+		stdout := captureStdout(t, func() {
+			err = runCmd(t, "urls", "ls", owner+"/other-env", "-o", "json")
+		})
+		assert.Success(t, "list devurls of "+owner+"/other-env", err)
+		assert.True(t, "lists the devurls of "+owner+"/other-env", strings.Contains(stdout, `"port":8080`))
+	}
+
+	var err error
+	captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "othr/other-env")
+	})
+	assert.ErrorContains(t, "unknown owner", err, `user "othr" not found`)
+
+	captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "other/env1")
+	})
+	assert.ErrorContains(t, "environment of another owner", err, "failed to find environment")
+
+	captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "other/other-env", "--user", fakeOtherUserID)
+	})
+	assert.ErrorContains(t, "owner with --user", err, "can't be used with --user")
+
+	for _, name := range []string{"/env1", "other/", "a/b/c"} {
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "ls", name)
+		})
+		assert.ErrorContains(t, "invalid owner-qualified name "+name, err, "expected the form owner/name")
+	}
+}
+
+func TestListDevURLsFields(t *testing.T) {
+	newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE", Name: "web"})
+
+	var err error
+	output := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "csv", "--fields", "name, PORT,id,environment")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.Equal(t, "csv", "Name,Port,ID,Environment\nweb,8080,url-id,env1\n", output)
+
+	output = captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "--fields", "url,name", "--no-headers")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.Equal(t, "table", []string{"8080.coder.com", "web"}, strings.Fields(output))
+
+	captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "--fields", "url,owner")
+	})
+	assert.ErrorContains(t, "unknown field", err, `unknown --fields value "owner"`)
+
+	captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "--fields", "url", "-o", "json")
+	})
+	assert.ErrorContains(t, "fields with json", err, "--fields only supports")
+}
+
+func TestWildcardDevURLKept(t *testing.T) {
+	wildcard := coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE", Scheme: "http", Wildcard: true}
+	dir := t.TempDir()
+
+	t.Run("create port tuple", func(t *testing.T) {
+		fake := newFakeCemanager(t, wildcard)
+		captureStdout(t, func() {
+			err := runCmd(t, "urls", "create", "env1", "--port", "8080:org")
+			assert.Success(t, "update wildcard devurl", err)
+		})
+		requests := fake.Requests()
+		assert.Equal(t, "requests", 1, len(requests))
+		assert.True(t, "wildcard kept", requests[0].Body.Wildcard)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		fake := newFakeCemanager(t, wildcard)
+		err := runCmd(t, "urls", "rename", "env1", "8080", "web")
+		assert.ErrorContains(t, "rename wildcard devurl", err, "wildcard devurls cannot be named")
+		assert.Equal(t, "no requests", 0, len(fake.Requests()))
+	})
+
+	t.Run("export and apply", func(t *testing.T) {
+		newFakeCemanager(t, wildcard)
+		var err error
+		manifest := captureStdout(t, func() {
+			err = runCmd(t, "urls", "export", "env1")
+		})
+		assert.Success(t, "export devurls", err)
+		assert.True(t, "wildcard exported", strings.Contains(manifest, "wildcard: true"))
+
+		file := filepath.Join(dir, "devurls.yaml")
+		assert.Success(t, "write manifest", ioutil.WriteFile(file, []byte(strings.Replace(manifest, "access: private", "access: org", 1)), 0600))
+		fake := newFakeCemanager(t, wildcard)
+		captureStdout(t, func() {
+			err = runCmd(t, "urls", "apply", "env1", "-f", file, "--yes")
+		})
+		assert.Success(t, "apply manifest", err)
+		requests := fake.Requests()
+		assert.Equal(t, "requests", 1, len(requests))
+		assert.Equal(t, "access updated", "ORG", requests[0].Body.Access)
+		assert.True(t, "wildcard kept", requests[0].Body.Wildcard)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		file := filepath.Join(dir, "import.yaml")
+		assert.Success(t, "write manifest", ioutil.WriteFile(file, []byte("- port: 8080\n  access: org\n  wildcard: true\n"), 0600))
+		fake := newFakeCemanager(t, wildcard)
+		captureStdout(t, func() {
+			err := runCmd(t, "urls", "import", "env1", "-f", file, "--existing", "update")
+			assert.Success(t, "import manifest", err)
+		})
+		requests := fake.Requests()
+		assert.Equal(t, "requests", 1, len(requests))
+		assert.True(t, "wildcard kept", requests[0].Body.Wildcard)
+	})
+
+	t.Run("named wildcard manifest", func(t *testing.T) {
+		file := filepath.Join(dir, "named.yaml")
+		assert.Success(t, "write manifest", ioutil.WriteFile(file, []byte("- port: 8080\n  name: web\n  wildcard: true\n"), 0600))
+		newFakeCemanager(t)
+		err := runCmd(t, "urls", "apply", "env1", "-f", file, "--yes")
+		assert.ErrorContains(t, "named wildcard entry", err, "wildcard devurls cannot be named")
+	})
+}
+
+func TestApplyImportDevURLsAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "devurls.yaml")
+	err := ioutil.WriteFile(manifest, []byte("- port: 8080\n  access: public\n- port: 3000\n  access: public\n"), 0600)
+	assert.Success(t, "write manifest", err)
+	readAudit := func(path string) []devURLAuditEntry {
+		raw, err := ioutil.ReadFile(path)
+		assert.Success(t, "read audit log", err)
+		var entries []devURLAuditEntry
+		for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+			var entry devURLAuditEntry
+			assert.Success(t, "decode audit line", json.Unmarshal([]byte(line), &entry))
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+	existing := coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "ORG", Scheme: "http"}
+
+	t.Run("apply", func(t *testing.T) {
+		newFakeCemanager(t, existing)
+		auditLog := filepath.Join(dir, "apply.log")
+		captureStdout(t, func() {
+			err := runCmd(t, "urls", "apply", "env1", "-f", manifest, "--yes", "--audit-log", auditLog)
+			assert.Success(t, "apply manifest", err)
+		})
+		entries := readAudit(auditLog)
+		assert.Equal(t, "audit lines", 2, len(entries))
+		assert.Equal(t, "updated port", 8080, entries[0].Port)
+		assert.Equal(t, "old access", "ORG", entries[0].OldAccess)
+		assert.Equal(t, "created port", 3000, entries[1].Port)
+		assert.Equal(t, "created old access", "", entries[1].OldAccess)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		newFakeCemanager(t, existing)
+		auditLog := filepath.Join(dir, "import.log")
+		captureStdout(t, func() {
+			err := runCmd(t, "urls", "import", "env1", "-f", manifest, "--yes", "--audit-log", auditLog)
+			assert.Success(t, "import manifest", err)
+		})
+		entries := readAudit(auditLog)
+		assert.Equal(t, "audit lines", 1, len(entries))
+		assert.Equal(t, "created port", 3000, entries[0].Port)
+		assert.Equal(t, "new access", "PUBLIC", entries[0].NewAccess)
+	})
+}
+
+func TestImportPublicDevURLsConfirmation(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "devurls.yaml")
+	err := ioutil.WriteFile(manifest, []byte("- port: 8080\n  access: public\n- port: 3000\n  access: org\n"), 0600)
+	assert.Success(t, "write manifest", err)
+
+	fake := newFakeCemanager(t)
+	captureStdout(t, func() {
+		err = runCmd(t, "urls", "import", "env1", "-f", manifest)
+	})
+	assert.ErrorContains(t, "public import needs confirmation", err, "refusing to create a public devurl without confirmation")
+	assert.Equal(t, "no requests", 0, len(fake.Requests()))
+
+	// Devurls which are already public don't need confirmation.
+	fake = newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "PUBLIC", Scheme: "http"})
+	captureStdout(t, func() {
+		err = runCmd(t, "urls", "import", "env1", "-f", manifest, "--existing", "update")
+	})
+	assert.Success(t, "import without public transitions", err)
+	assert.Equal(t, "requests", 1, len(fake.Requests()))
+}
+
+func TestDevURLsPrettyJSON(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "devurls.yaml")
+	err := ioutil.WriteFile(manifest, []byte("- port: 8080\n"), 0600)
+	assert.Success(t, "write manifest", err)
+
+	tests := [][]string{
+		{"urls", "create", "env1", "3000", "-o", "json", "--pretty"},
+		{"urls", "rm", "env1", "8080", "-o", "json", "--pretty"},
+		{"urls", "create", "env1", "3000", "-o", "json", "--dry-run", "--pretty"},
+		{"urls", "apply", "env1", "-f", manifest, "--plan", "-o", "json", "--pretty"},
+		{"urls", "diff", "env1", "-f", manifest, "-o", "json", "--pretty"},
+	}
+	for _, args := range tests {
+		newFakeCemanager(t, coder.DevURL{ID: "url-id", URL: "8080.coder.com", Port: 8080, Access: "ORG", Scheme: "http"})
+		output := captureStdout(t, func() {
+			_ = runCmd(t, args...)
+		})
+		assert.True(t, strings.Join(args, " ")+" is indented", strings.Contains(output, "\n  "))
+	}
+}
+
+func TestListDevURLsPretty(t *testing.T) {
+	newFakeCemanager(t,
+		coder.DevURL{ID: "url-1", URL: "8080.coder.com", Port: 8080, Access: "PRIVATE"},
+		coder.DevURL{ID: "url-2", URL: "3000.coder.com", Port: 3000, Access: "ORG"},
+	)
+
+	var err error
+	compact := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "json")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.Equal(t, "compact output is a single line", 1, strings.Count(compact, "\n"))
+	assert.True(t, "compact output isn't indented", !strings.Contains(compact, "  "))
+
+	pretty := captureStdout(t, func() {
+		err = runCmd(t, "urls", "ls", "env1", "-o", "json", "--pretty")
+	})
+	assert.Success(t, "list devurls", err)
+	assert.True(t, "pretty output is indented", strings.Contains(pretty, "\n  {\n    \"id\": \"url-1\""))
+
+	var compactURLs, prettyURLs []devURLRecord
+	assert.Success(t, "decode compact output", json.Unmarshal([]byte(compact), &compactURLs))
+	assert.Success(t, "decode pretty output", json.Unmarshal([]byte(pretty), &prettyURLs))
+	assert.Equal(t, "same devurls", compactURLs, prettyURLs)
+}
@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v2"
+
+	"cdr.dev/coder-cli/coder-sdk"
+	"cdr.dev/coder-cli/pkg/clog"
+	"cdr.dev/coder-cli/pkg/tablewriter"
+)
+
+// devURLManifestEntry is the desired state of a single devURL, as declared in a manifest file.
+type devURLManifestEntry struct {
+	Port   int    `json:"port"   yaml:"port"`
+	Name   string `json:"name"   yaml:"name"`
+	Access string `json:"access" yaml:"access"`
+	Scheme string `json:"scheme" yaml:"scheme"`
+	// Wildcard devURLs also route every subdomain of their hostname to the port.
+	Wildcard bool `json:"wildcard,omitempty" yaml:"wildcard,omitempty"`
+}
+
+// devURLChange is a single step to converge the devURLs of an environment to their desired state.
+type devURLChange struct {
+	Action string
+	// DevURL holds the current state of the devURL, if any.
+	DevURL *coder.DevURL
+	// Entry holds the desired state of the devURL, unless it is being deleted.
+	Entry *devURLManifestEntry
+}
+
+// plannedAction is the row of the plan shown by urls apply for a devURLChange, with the
+// desired state of the devURL, or its current state when it is deleted.
+type plannedAction struct {
+	Action string `json:"action" table:"Action"`
+	Port   int    `json:"port"   table:"Port,right"`
+	Name   string `json:"name"   table:"Name"`
+	Access string `json:"access" table:"Access"`
+	Scheme string `json:"scheme" table:"Scheme"`
+}
+
+// planActions returns the plannedAction of each change.
+func planActions(changes []devURLChange) []plannedAction {
+	actions := make([]plannedAction, 0, len(changes))
+	for _, change := range changes {
+		action := plannedAction{Action: change.Action}
+		if entry := change.Entry; entry != nil {
+			action.Port, action.Name, action.Access, action.Scheme = entry.Port, entry.Name, entry.Access, entry.Scheme
+		} else {
+			url := change.DevURL
+			action.Port, action.Name, action.Access, action.Scheme = url.Port, url.Name, strings.ToUpper(url.Access), url.Scheme
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// Actions of a devURLChange.
+const (
+	devURLCreate = "create"
+	devURLUpdate = "update"
+	devURLDelete = "delete"
+)
+
+func applyDevURLsCmd() *cobra.Command {
+	var (
+		file      string
+		prune     bool
+		yes       bool
+		planOnly  bool
+		outputFmt string
+		pretty    bool
+		auditLog  string
+	)
+	cmd := &cobra.Command{
+		Use:   "apply [env_name] -f [manifest]",
+		Short: "Converge the devurls of an environment to the ones declared in a manifest",
+		Long: `Converge the devurls of an environment to the ones declared in a manifest.
+
+The manifest is a YAML or JSON list of devurls with a port, and an optional name, access level, scheme
+and wildcard setting.
+Devurls are matched against the manifest by port. Missing devurls are created and the ones
+which differ are updated. Devurls absent from the manifest are only deleted with --prune.
+
+The planned changes are shown before being applied, which must be confirmed unless --yes is passed.
+Making a devurl public writes a json audit line to stderr, or appends it to --audit-log.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: getDevURLEnvsForCompletion,
+		Example: `coder urls apply my-env -f devurls.yaml
+cat devurls.json | coder urls apply my-env -f - --prune --yes
+coder urls apply my-env -f devurls.yaml --plan -o json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				envName = args[0]
+				ctx     = cmd.Context()
+			)
+
+			if outputFmt != humanOutput && outputFmt != jsonOutput {
+				return xerrors.Errorf("unknown --output value %q", outputFmt)
+			}
+			entries, err := readDevURLManifest(file)
+			if err != nil {
+				return err
+			}
+
+			client, err := newClientWithTimeout(ctx)
+			if err != nil {
+				return err
+			}
+			env, err := findEnvWithTimeout(ctx, client, envName)
+			if err != nil {
+				return err
+			}
+			urls, err := urlListForEnv(ctx, client, env)
+			if err != nil {
+				return err
+			}
+
+			changes, kept := planDevURLChanges(entries, urls, prune)
+			for _, url := range kept {
+				clog.LogInfo(fmt.Sprintf("keeping devurl for port %v absent from the manifest", url.Port), clog.Tipf("use --prune to delete it"))
+			}
+			actions := planActions(changes)
+			if outputFmt == jsonOutput {
+				if err := newJSONEncoder(os.Stdout, pretty).Encode(actions); err != nil {
+					return xerrors.Errorf("encode plan as json: %w", err)
+				}
+			}
+			if len(changes) < 1 {
+				clog.LogSuccess("devurls are up to date")
+				return nil
+			}
+			if outputFmt == humanOutput {
+				err := tablewriter.WriteTable(len(actions), func(i int) interface{} { return actions[i] })
+				if err != nil {
+					return xerrors.Errorf("write plan table: %w", err)
+				}
+			}
+			if planOnly {
+				return nil
+			}
+			if !yes {
+				confirm := promptui.Prompt{
+					Label:     fmt.Sprintf("Apply %d change(s) to the devurls of environment %q?", len(changes), env.Name),
+					IsConfirm: true,
+				}
+				if _, err := confirm.Run(); err != nil {
+					return clog.Fatal(
+						"failed to confirm the plan", clog.BlankLine,
+						clog.Tipf(`use "--yes" to apply without a confirmation prompt, or "--plan" to only show the plan`),
+					)
+				}
+			}
+			audit := &devURLAuditLog{path: auditLog}
+			for _, change := range changes {
+				if err := applyDevURLChange(ctx, client, env, change, audit); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", `manifest file to apply, "-" reads from stdin`)
+	cmd.Flags().BoolVar(&prune, "prune", false, "delete the devurls which are absent from the manifest")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "apply the plan without prompting for confirmation")
+	cmd.Flags().BoolVar(&planOnly, "plan", false, "only show the planned changes, without applying them")
+	cmd.Flags().StringVarP(&outputFmt, "output", "o", humanOutput, "format of the plan, human|json")
+	cmd.Flags().BoolVar(&pretty, "pretty", false, "indent json output")
+	cmd.Flags().StringVar(&auditLog, "audit-log", "", "append the json audit lines of devurls made public to this file instead of stderr")
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+// readDevURLManifest reads and validates the manifest at the given path, or from stdin when path is "-".
+// Unset access levels and schemes take the defaults of urls create.
+func readDevURLManifest(path string) ([]devURLManifestEntry, error) {
+	var (
+		raw []byte
+		err error
+	)
+	if path == "-" {
+		raw, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		raw, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("read manifest: %w", err)
+	}
+
+	// NOTE: JSON is valid YAML, so this handles both formats.
+	var entries []devURLManifestEntry
+	if err := yaml.UnmarshalStrict(raw, &entries); err != nil {
+		return nil, clog.Error(fmt.Sprintf("invalid manifest %q", path), clog.Causef(err.Error()))
+	}
+
+	ports := make(map[int]bool, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Port < 1 || entry.Port > 65535 {
+			return nil, xerrors.Errorf("manifest entry %d: invalid port %d", i, entry.Port)
+		}
+		if ports[entry.Port] {
+			return nil, xerrors.Errorf("manifest entry %d: duplicate port %d", i, entry.Port)
+		}
+		ports[entry.Port] = true
+
+		if entry.Name != "" && !devURLNameValidRx.MatchString(entry.Name) {
+			return nil, xerrors.Errorf("manifest entry %d: invalid name %q", i, entry.Name)
+		}
+		if entry.Name != "" && entry.Wildcard {
+			return nil, xerrors.Errorf("manifest entry %d: wildcard devurls cannot be named", i)
+		}
+		entry.Access = strings.ToUpper(entry.Access)
+		if entry.Access == "" {
+			entry.Access = defaultDevURLAccess()
+		}
+		if !accessLevelIsValid(entry.Access) {
+			return nil, xerrors.Errorf("manifest entry %d: invalid access level %q", i, entry.Access)
+		}
+		entry.Scheme = strings.ToLower(entry.Scheme)
+		if entry.Scheme == "" {
+			entry.Scheme = defaultDevURLScheme()
+		}
+		if !schemeIsValid(entry.Scheme) {
+			return nil, xerrors.Errorf("manifest entry %d: invalid scheme %q", i, entry.Scheme)
+		}
+	}
+	return entries, nil
+}
+
+// planDevURLChanges diffs the desired devURLs against the current ones. Creations and updates come
+// in manifest order, followed by deletions sorted by port. Without prune, the devURLs absent from
+// the manifest are returned as kept instead of being deleted.
+func planDevURLChanges(entries []devURLManifestEntry, urls []coder.DevURL, prune bool) (changes []devURLChange, kept []coder.DevURL) {
+	desired := make(map[int]bool, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		desired[entry.Port] = true
+
+		url, found := devURLByPort(entry.Port, urls)
+		switch {
+		case !found:
+			changes = append(changes, devURLChange{Action: devURLCreate, Entry: entry})
+		case !devURLMatchesEntry(*url, *entry):
+			changes = append(changes, devURLChange{Action: devURLUpdate, DevURL: url, Entry: entry})
+		}
+	}
+
+	var undeclared []coder.DevURL
+	for _, url := range urls {
+		if !desired[url.Port] {
+			undeclared = append(undeclared, url)
+		}
+	}
+	sort.SliceStable(undeclared, func(i, j int) bool { return undeclared[i].Port < undeclared[j].Port })
+	if !prune {
+		return changes, undeclared
+	}
+	for i := range undeclared {
+		changes = append(changes, devURLChange{Action: devURLDelete, DevURL: &undeclared[i]})
+	}
+	return changes, nil
+}
+
+// devURLMatchesEntry reports whether the devURL is in the state declared by the manifest entry.
+func devURLMatchesEntry(url coder.DevURL, entry devURLManifestEntry) bool {
+	return url.Name == entry.Name && strings.EqualFold(url.Access, entry.Access) && url.Scheme == entry.Scheme && url.Wildcard == entry.Wildcard
+}
+
+// applyDevURLChange sends the request corresponding to the given change, auditing the devURLs it makes public.
+func applyDevURLChange(ctx context.Context, client *coder.Client, env *coder.Environment, change devURLChange, audit *devURLAuditLog) error {
+	var req coder.CreateDevURLReq
+	var port int
+	if change.DevURL != nil {
+		port = change.DevURL.Port
+	}
+	if entry := change.Entry; entry != nil {
+		port = entry.Port
+		req = coder.CreateDevURLReq{
+			Port:     entry.Port,
+			Name:     entry.Name,
+			Access:   entry.Access,
+			EnvID:    env.ID,
+			Scheme:   entry.Scheme,
+			Wildcard: entry.Wildcard,
+		}
+	}
+
+	defer recordTiming(fmt.Sprintf("%s devurl for port %v", change.Action, port), time.Now())
+	switch change.Action {
+	case devURLCreate:
+		if err := createDevURLWithRetries(ctx, client, env.ID, req); err != nil {
+			return insertDevURLError(err, req.Name)
+		}
+		clog.LogSuccess(fmt.Sprintf("created devurl for port %v", req.Port))
+		return audit.recordAccess(env.Name, req.Port, "", req.Access)
+	case devURLUpdate:
+		err := withAPIRetries(ctx, func(ctx context.Context) error {
+			return client.PutDevURL(ctx, env.ID, change.DevURL.ID, coder.PutDevURLReq(req))
+		})
+		if err != nil {
+			return xerrors.Errorf("update DevURL: %w", err)
+		}
+		clog.LogSuccess(fmt.Sprintf("updated devurl for port %v", req.Port))
+		return audit.recordAccess(env.Name, req.Port, change.DevURL.Access, req.Access)
+	case devURLDelete:
+		if err := deleteDevURLWithRetries(ctx, client, env.ID, change.DevURL.ID); err != nil {
+			return xerrors.Errorf("delete DevURL: %w", err)
+		}
+		clog.LogSuccess(fmt.Sprintf("deleted devurl for port %v", change.DevURL.Port))
+	default:
+		return xerrors.Errorf("unknown devurl change %q", change.Action)
+	}
+	return nil
+}
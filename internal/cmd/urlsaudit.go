@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// devURLAuditEntry is the audit line written when a devURL becomes public.
+type devURLAuditEntry struct {
+	Time        time.Time `json:"time"`
+	Environment string    `json:"environment"`
+	Port        int       `json:"port"`
+	// OldAccess is empty when the devURL was created public.
+	OldAccess string `json:"old_access"`
+	NewAccess string `json:"new_access"`
+}
+
+// devURLAuditLog writes the devURL audit lines as json, to the file at path, or to stderr when path is empty.
+type devURLAuditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// recordAccess writes an audit line when the access level of a devURL changes from oldAccess to PUBLIC.
+func (l *devURLAuditLog) recordAccess(envName string, port int, oldAccess, newAccess string) error {
+	if !strings.EqualFold(newAccess, "PUBLIC") || strings.EqualFold(oldAccess, "PUBLIC") {
+		return nil
+	}
+	entry := devURLAuditEntry{
+		Time:        time.Now().UTC(),
+		Environment: envName,
+		Port:        port,
+		OldAccess:   strings.ToUpper(oldAccess),
+		NewAccess:   strings.ToUpper(newAccess),
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var w io.Writer = os.Stderr
+	if l.path != "" {
+		f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return xerrors.Errorf("open audit log: %w", err)
+		}
+		defer func() { _ = f.Close() }() // Best effort.
+		w = f
+	}
+	if err := json.NewEncoder(w).Encode(entry); err != nil {
+		return xerrors.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
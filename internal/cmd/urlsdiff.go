@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/coder-cli/pkg/clog"
+)
+
+// devURLDrift is a difference between the devURL of a port and its declaration in a manifest.
+type devURLDrift struct {
+	Action string `json:"action"`
+	Port   int    `json:"port"`
+	// Current holds the live devURL, unless it is missing.
+	Current *devURLManifestEntry `json:"current,omitempty"`
+	// Desired holds the declared devURL, unless it is absent from the manifest.
+	Desired *devURLManifestEntry `json:"desired,omitempty"`
+}
+
+func diffDevURLsCmd() *cobra.Command {
+	var (
+		file      string
+		outputFmt string
+		pretty    bool
+	)
+	cmd := &cobra.Command{
+		Use:   "diff [env_name] -f [manifest]",
+		Short: "Show the drift between the devurls of an environment and a manifest",
+		Long: `Show the drift between the devurls of an environment and the ones declared in a manifest.
+
+Devurls are matched by port, as with urls apply --prune. Missing devurls are shown as additions,
+undeclared ones as removals, and the ones whose name, access level, scheme or wildcard setting differ as changes.
+The command fails when there is any drift, so it can be used as a CI check.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: getDevURLEnvsForCompletion,
+		Example: `coder urls diff my-env -f devurls.yaml
+coder urls diff my-env -f devurls.yaml -o json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if outputFmt != humanOutput && outputFmt != jsonOutput {
+				return xerrors.Errorf("unknown --output value %q", outputFmt)
+			}
+			entries, err := readDevURLManifest(file)
+			if err != nil {
+				return err
+			}
+
+			client, err := newClientWithTimeout(ctx)
+			if err != nil {
+				return err
+			}
+			urls, err := urlList(ctx, client, args[0])
+			if err != nil {
+				return err
+			}
+
+			changes, _ := planDevURLChanges(entries, urls, true)
+			drifts := devURLDrifts(changes)
+			if outputFmt == jsonOutput {
+				if err := newJSONEncoder(os.Stdout, pretty).Encode(drifts); err != nil {
+					return xerrors.Errorf("encode drift as json: %w", err)
+				}
+			} else {
+				for _, drift := range drifts {
+					fmt.Println(drift.String())
+				}
+			}
+			if len(drifts) > 0 {
+				return clog.Error(
+					fmt.Sprintf("found %d devurl(s) drifting from the manifest", len(drifts)),
+					clog.Tipf(`run "coder urls apply %s -f %s --prune" to converge them`, args[0], file),
+				)
+			}
+			clog.LogSuccess("devurls match the manifest")
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", `manifest file to compare against, "-" reads from stdin`)
+	cmd.Flags().StringVarP(&outputFmt, "output", "o", humanOutput, "format of the drift, human|json")
+	cmd.Flags().BoolVar(&pretty, "pretty", false, "indent json output")
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+// devURLDrifts returns the devURLDrift of each change, with lowercase access levels as in manifests.
+func devURLDrifts(changes []devURLChange) []devURLDrift {
+	drifts := make([]devURLDrift, 0, len(changes))
+	for _, change := range changes {
+		drift := devURLDrift{Action: change.Action}
+		if change.DevURL != nil {
+			drift.Current = &devURLManifestEntry{
+				Port:     change.DevURL.Port,
+				Name:     change.DevURL.Name,
+				Access:   strings.ToLower(change.DevURL.Access),
+				Scheme:   change.DevURL.Scheme,
+				Wildcard: change.DevURL.Wildcard,
+			}
+			drift.Port = drift.Current.Port
+		}
+		if change.Entry != nil {
+			desired := *change.Entry
+			desired.Access = strings.ToLower(desired.Access)
+			drift.Desired = &desired
+			drift.Port = desired.Port
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts
+}
+
+// String formats the drift as a colorized diff line.
+func (d devURLDrift) String() string {
+	switch d.Action {
+	case devURLCreate:
+		return color.GreenString("+ %s", formatManifestEntry(*d.Desired))
+	case devURLDelete:
+		return color.RedString("- %s", formatManifestEntry(*d.Current))
+	}
+	var fields []string
+	for _, field := range []struct{ name, current, desired string }{
+		{"name", d.Current.Name, d.Desired.Name},
+		{"access", d.Current.Access, d.Desired.Access},
+		{"scheme", d.Current.Scheme, d.Desired.Scheme},
+		{"wildcard", strconv.FormatBool(d.Current.Wildcard), strconv.FormatBool(d.Desired.Wildcard)},
+	} {
+		if field.current != field.desired {
+			fields = append(fields, fmt.Sprintf("%s %q -> %q", field.name, field.current, field.desired))
+		}
+	}
+	return color.YellowString("~ port %d: %s", d.Port, strings.Join(fields, ", "))
+}
+
+// formatManifestEntry formats the devURL of a manifest on a single line.
+func formatManifestEntry(entry devURLManifestEntry) string {
+	s := fmt.Sprintf("port %d: name %q, access %q, scheme %q", entry.Port, entry.Name, entry.Access, entry.Scheme)
+	if entry.Wildcard {
+		s += ", wildcard"
+	}
+	return s
+}
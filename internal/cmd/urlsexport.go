@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v2"
+
+	"cdr.dev/coder-cli/coder-sdk"
+	"cdr.dev/coder-cli/pkg/clog"
+)
+
+// Behaviors of urls import for the ports which already have a devURL.
+const (
+	importSkipExisting   = "skip"
+	importUpdateExisting = "update"
+)
+
+func exportDevURLsCmd() *cobra.Command {
+	var outputFmt string
+	cmd := &cobra.Command{
+		Use:   "export [env_name]",
+		Short: "Print the devurls of an environment as a manifest",
+		Long: `Print the devurls of an environment as a manifest, sorted by port.
+
+The manifest can be given to urls import or urls apply to recreate the devurls in another environment.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: getDevURLEnvsForCompletion,
+		Example: `coder urls export my-env > devurls.yaml
+coder urls export my-env | coder urls import my-env-copy -f -`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if outputFmt != yamlOutput && outputFmt != jsonOutput {
+				return xerrors.Errorf("unknown --output value %q", outputFmt)
+			}
+
+			client, err := newClientWithTimeout(ctx)
+			if err != nil {
+				return err
+			}
+			urls, err := urlList(ctx, client, args[0])
+			if err != nil {
+				return err
+			}
+			entries := devURLManifest(urls)
+
+			if outputFmt == jsonOutput {
+				if err := newJSONEncoder(os.Stdout, true).Encode(entries); err != nil {
+					return xerrors.Errorf("encode manifest as json: %w", err)
+				}
+				return nil
+			}
+			if err := yaml.NewEncoder(os.Stdout).Encode(entries); err != nil {
+				return xerrors.Errorf("encode manifest as yaml: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&outputFmt, "output", "o", yamlOutput, "format of the manifest, yaml|json")
+	return cmd
+}
+
+// devURLManifest returns the manifest declaring the given devURLs, sorted by port.
+func devURLManifest(urls []coder.DevURL) []devURLManifestEntry {
+	entries := make([]devURLManifestEntry, 0, len(urls))
+	for _, url := range urls {
+		entries = append(entries, devURLManifestEntry{
+			Port:     url.Port,
+			Name:     url.Name,
+			Access:   strings.ToLower(url.Access),
+			Scheme:   url.Scheme,
+			Wildcard: url.Wildcard,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Port < entries[j].Port })
+	return entries
+}
+
+func importDevURLsCmd() *cobra.Command {
+	var (
+		file     string
+		existing string
+		auditLog string
+		yes      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "import [env_name] -f [manifest]",
+		Short: "Create the devurls declared in a manifest in an environment",
+		Long: `Create the devurls declared in a manifest, such as the one printed by urls export, in an environment.
+
+Ports which already have a devurl are skipped, unless --existing update is passed to update them
+to their declared name, access level, scheme and wildcard setting. Unlike urls apply, devurls absent from the manifest are never deleted.
+Public devurls must be confirmed unless --yes is passed, which is required when the manifest is read from stdin.
+Making a devurl public writes a json audit line to stderr, or appends it to --audit-log.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: getDevURLEnvsForCompletion,
+		Example: `coder urls import my-env -f devurls.yaml
+coder urls export my-env | coder urls import my-env-copy -f - --existing update --yes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if existing != importSkipExisting && existing != importUpdateExisting {
+				return clog.Error(
+					fmt.Sprintf("invalid --existing value %q", existing),
+					clog.Hintf("valid values are %q", []string{importSkipExisting, importUpdateExisting}),
+				)
+			}
+			entries, err := readDevURLManifest(file)
+			if err != nil {
+				return err
+			}
+
+			client, err := newClientWithTimeout(ctx)
+			if err != nil {
+				return err
+			}
+			env, err := findEnvWithTimeout(ctx, client, args[0])
+			if err != nil {
+				return err
+			}
+			urls, err := urlListForEnv(ctx, client, env)
+			if err != nil {
+				return err
+			}
+
+			var changes []devURLChange
+			for i := range entries {
+				entry := &entries[i]
+				change := devURLChange{Action: devURLCreate, Entry: entry}
+				if url, found := devURLByPort(entry.Port, urls); found {
+					if existing == importSkipExisting {
+						clog.LogInfo(fmt.Sprintf("skipping port %v, which already has a devurl", entry.Port), clog.Tipf("use --existing update to update it"))
+						continue
+					}
+					if devURLMatchesEntry(*url, *entry) {
+						continue
+					}
+					change = devURLChange{Action: devURLUpdate, DevURL: url, Entry: entry}
+				}
+				changes = append(changes, change)
+			}
+			if !yes {
+				if err := confirmPublicDevURLChanges(changes); err != nil {
+					return err
+				}
+			}
+
+			audit := &devURLAuditLog{path: auditLog}
+			for _, change := range changes {
+				if err := applyDevURLChange(ctx, client, env, change, audit); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", `manifest file to import, "-" reads from stdin`)
+	cmd.Flags().StringVar(&existing, "existing", importSkipExisting, "what to do with the ports which already have a devurl, skip|update")
+	cmd.Flags().StringVar(&auditLog, "audit-log", "", "append the json audit lines of devurls made public to this file instead of stderr")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "import public devurls without prompting for confirmation")
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
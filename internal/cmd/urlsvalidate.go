@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v2"
+
+	"cdr.dev/coder-cli/coder-sdk"
+	"cdr.dev/coder-cli/pkg/clog"
+	"cdr.dev/coder-cli/pkg/tablewriter"
+)
+
+// devURLPolicy holds the rules checked by urls validate, as declared in a policy file.
+type devURLPolicy struct {
+	// Access lists the allowed access levels, any level is allowed when empty.
+	Access []string `yaml:"access"`
+	// NamePattern is the regular expression devURL names must match, in place of the default naming rules.
+	NamePattern string `yaml:"name_pattern"`
+	// DisallowedPorts lists the ports which must not be exposed.
+	DisallowedPorts []int `yaml:"disallowed_ports"`
+
+	nameRx *regexp.Regexp
+}
+
+// devURLViolation is a devURL breaking a rule of the policy.
+type devURLViolation struct {
+	Port   int    `json:"port"   table:"Port,right"`
+	Name   string `json:"name"   table:"Name"`
+	Rule   string `json:"rule"   table:"Rule"`
+	Reason string `json:"reason" table:"Reason"`
+}
+
+// Rules of a devURLPolicy.
+const (
+	policyAccessRule = "access"
+	policyNameRule   = "name_pattern"
+	policyPortRule   = "disallowed_ports"
+)
+
+func validateDevURLsCmd() *cobra.Command {
+	var (
+		policyFile   string
+		manifestFile string
+		outputFmt    string
+		pretty       bool
+	)
+	cmd := &cobra.Command{
+		Use:   "validate [env_name] --policy [policy]",
+		Short: "Check the devurls of an environment, or of a manifest, against a policy",
+		Long: `Check the devurls of an environment, or of a manifest, against a policy.
+
+The policy is a YAML file with the following optional rules:
+
+  access: [private, org]         # allowed access levels
+  name_pattern: "^[a-z]+-svc$"   # regular expression devurl names must match
+  disallowed_ports: [22, 5432]   # ports which must not be exposed
+
+The violations are shown as a table, and the command fails if there are any.
+Devurls without a name are not checked against the name pattern.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if manifestFile != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		ValidArgsFunction: getDevURLEnvsForCompletion,
+		Example: `coder urls validate my-env --policy policy.yaml
+coder urls validate --policy policy.yaml -f devurls.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if outputFmt != humanOutput && outputFmt != jsonOutput {
+				return xerrors.Errorf("unknown --output value %q", outputFmt)
+			}
+			policy, err := readDevURLPolicy(policyFile)
+			if err != nil {
+				return err
+			}
+
+			var urls []coder.DevURL
+			if manifestFile != "" {
+				entries, err := readDevURLManifest(manifestFile)
+				if err != nil {
+					return err
+				}
+				for _, entry := range entries {
+					urls = append(urls, coder.DevURL{Port: entry.Port, Name: entry.Name, Access: entry.Access, Scheme: entry.Scheme})
+				}
+			} else {
+				client, err := newClientWithTimeout(ctx)
+				if err != nil {
+					return err
+				}
+				if urls, err = urlList(ctx, client, args[0]); err != nil {
+					return err
+				}
+			}
+
+			violations := policy.check(urls)
+			if outputFmt == jsonOutput {
+				if err := newJSONEncoder(os.Stdout, pretty).Encode(violations); err != nil {
+					return xerrors.Errorf("encode violations as json: %w", err)
+				}
+			} else if len(violations) > 0 {
+				err := tablewriter.WriteTable(len(violations), func(i int) interface{} { return violations[i] })
+				if err != nil {
+					return xerrors.Errorf("write violations table: %w", err)
+				}
+			}
+			if len(violations) > 0 {
+				return clog.Error(fmt.Sprintf("found %d policy violation(s)", len(violations)))
+			}
+			clog.LogSuccess(fmt.Sprintf("the %d devurl(s) comply with the policy", len(urls)))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&policyFile, "policy", "", "policy file to check the devurls against")
+	cmd.Flags().StringVarP(&manifestFile, "file", "f", "", `manifest file to check instead of the devurls of an environment, "-" reads from stdin`)
+	cmd.Flags().StringVarP(&outputFmt, "output", "o", humanOutput, "format of the violations, human|json")
+	cmd.Flags().BoolVar(&pretty, "pretty", false, "indent json output")
+	_ = cmd.MarkFlagRequired("policy")
+	return cmd
+}
+
+// readDevURLPolicy reads and validates the policy at the given path.
+func readDevURLPolicy(path string) (*devURLPolicy, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("read policy: %w", err)
+	}
+	var policy devURLPolicy
+	if err := yaml.UnmarshalStrict(raw, &policy); err != nil {
+		return nil, clog.Error(fmt.Sprintf("invalid policy %q", path), clog.Causef(err.Error()))
+	}
+
+	for i, level := range policy.Access {
+		parsed, err := coder.ParseAccessLevel(level)
+		if err != nil {
+			return nil, accessLevelError(xerrors.Errorf("invalid policy %q: %w", path, err), level)
+		}
+		policy.Access[i] = parsed
+	}
+	policy.nameRx = devURLNameValidRx
+	if policy.NamePattern != "" {
+		if policy.nameRx, err = regexp.Compile(policy.NamePattern); err != nil {
+			return nil, clog.Error(fmt.Sprintf("invalid name_pattern of policy %q", path), clog.Causef(err.Error()))
+		}
+	}
+	for _, port := range policy.DisallowedPorts {
+		if port < 1 || port > 65535 {
+			return nil, xerrors.Errorf("invalid policy %q: invalid disallowed port %d", path, port)
+		}
+	}
+	return &policy, nil
+}
+
+// check returns the violations of the policy by the given devURLs, in their order.
+func (p devURLPolicy) check(urls []coder.DevURL) []devURLViolation {
+	violations := []devURLViolation{}
+	for _, url := range urls {
+		violation := devURLViolation{Port: url.Port, Name: url.Name}
+		if len(p.Access) > 0 && !containsFold(p.Access, url.Access) {
+			violation.Rule = policyAccessRule
+			violation.Reason = fmt.Sprintf("access level %s is not one of %s", strings.ToUpper(url.Access), strings.Join(p.Access, ", "))
+			violations = append(violations, violation)
+		}
+		if url.Name != "" && !p.nameRx.MatchString(url.Name) {
+			violation.Rule = policyNameRule
+			violation.Reason = fmt.Sprintf("name doesn't match %q", p.nameRx)
+			violations = append(violations, violation)
+		}
+		for _, port := range p.DisallowedPorts {
+			if url.Port == port {
+				violation.Rule = policyPortRule
+				violation.Reason = fmt.Sprintf("port %d must not be exposed", port)
+				violations = append(violations, violation)
+			}
+		}
+	}
+	return violations
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
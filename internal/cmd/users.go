@@ -23,7 +23,7 @@ func usersCmd() *cobra.Command {
 coder users ls -o json | jq .[] | jq -r .email`,
 		RunE: listUsers(&outputFmt),
 	}
-	lsCmd.Flags().StringVarP(&outputFmt, "output", "o", humanOutput, "human | json")
+	lsCmd.Flags().StringVarP(&outputFmt, "output", "o", defaultOutputFormat(humanOutput, jsonOutput), "human | json, defaults to $"+outputEnv)
 
 	cmd.AddCommand(lsCmd)
 	return cmd
@@ -21,6 +21,7 @@ func (f File) Read() (string, error) {
 
 // Coder CLI configuration files.
 var (
-	Session File = "session"
-	URL     File = "url"
+	Session    File = "session"
+	URL        File = "url"
+	DefaultEnv File = "default_env"
 )
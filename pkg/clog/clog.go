@@ -1,15 +1,66 @@
 package clog
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/fatih/color"
+	"golang.org/x/crypto/ssh/terminal"
 	"golang.org/x/xerrors"
 )
 
+func init() {
+	if !colorSupported() {
+		DisableColor()
+	}
+}
+
+// colorSupported reports whether the environment allows colored output,
+// which requires NO_COLOR to be unset and both stdout and stderr to be terminals.
+func colorSupported() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return terminal.IsTerminal(int(os.Stdout.Fd())) && terminal.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// DisableColor removes the colors of every message. Note that this affects all output colored
+// by github.com/fatih/color.
+func DisableColor() {
+	color.NoColor = true
+}
+
+// quiet suppresses info and success messages when set.
+var quiet bool
+
+// SetQuiet sets whether info and success messages are suppressed, in which case
+// only warnings and errors are logged.
+func SetQuiet(q bool) {
+	quiet = q
+}
+
+// Formats in which messages may be logged.
+const (
+	FormatHuman = "human"
+	FormatJSON  = "json"
+)
+
+// format is the format in which messages are logged.
+var format = FormatHuman
+
+// SetFormat sets the format in which messages are logged, either FormatHuman or FormatJSON.
+func SetFormat(f string) error {
+	if f != FormatHuman && f != FormatJSON {
+		return xerrors.Errorf("unknown log format %q, expected one of %q", f, []string{FormatHuman, FormatJSON})
+	}
+	format = f
+	return nil
+}
+
 // CLIMessage provides a human-readable message for CLI errors and messages.
 type CLIMessage struct {
 	Level  string
@@ -37,6 +88,39 @@ func (m CLIMessage) String() string {
 	return str.String()
 }
 
+// ansiEscapeRx matches the terminal escape sequences used for colors.
+var ansiEscapeRx = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// jsonMessage is the structured representation of a CLIMessage.
+type jsonMessage struct {
+	Level   string   `json:"level"`
+	Message string   `json:"message"`
+	Lines   []string `json:"lines,omitempty"`
+}
+
+// JSON formats the CLI message as a single line json object, without colors.
+func (m CLIMessage) JSON() string {
+	msg := jsonMessage{
+		Level:   m.Level,
+		Message: ansiEscapeRx.ReplaceAllString(m.Header, ""),
+	}
+	for _, line := range m.Lines {
+		msg.Lines = append(msg.Lines, ansiEscapeRx.ReplaceAllString(line, ""))
+	}
+	// This can't fail as the message only holds strings.
+	raw, _ := json.Marshal(msg)
+	return string(raw) + "\n"
+}
+
+// write prints the given message to stderr in the configured format.
+func write(m CLIMessage) {
+	if format == FormatJSON {
+		fmt.Fprint(os.Stderr, m.JSON())
+		return
+	}
+	fmt.Fprint(os.Stderr, m.String())
+}
+
 // Log logs the given error to stderr, defaulting to "fatal" if the error is not a CLIError.
 // If the error is a CLIError, the plain error chain is ignored and the CLIError
 // is logged on its own.
@@ -45,37 +129,47 @@ func Log(err error) {
 	if !xerrors.As(err, &cliErr) {
 		cliErr = Fatal(err.Error())
 	}
+	if format == FormatJSON {
+		write(cliErr.CLIMessage)
+		return
+	}
 	fmt.Fprintln(os.Stderr, cliErr.String())
 }
 
-// LogInfo prints the given info message to stderr.
+// LogInfo prints the given info message to stderr, unless quiet.
 func LogInfo(header string, lines ...string) {
-	fmt.Fprint(os.Stderr, CLIMessage{
+	if quiet {
+		return
+	}
+	write(CLIMessage{
 		Level:  "info",
 		Color:  color.FgBlue,
 		Header: header,
 		Lines:  lines,
-	}.String())
+	})
 }
 
-// LogSuccess prints the given info message to stderr.
+// LogSuccess prints the given info message to stderr, unless quiet.
 func LogSuccess(header string, lines ...string) {
-	fmt.Fprint(os.Stderr, CLIMessage{
+	if quiet {
+		return
+	}
+	write(CLIMessage{
 		Level:  "success",
 		Color:  color.FgGreen,
 		Header: header,
 		Lines:  lines,
-	}.String())
+	})
 }
 
 // LogWarn prints the given warn message to stderr.
 func LogWarn(header string, lines ...string) {
-	fmt.Fprint(os.Stderr, CLIMessage{
+	write(CLIMessage{
 		Level:  "warning",
 		Color:  color.FgYellow,
 		Header: header,
 		Lines:  lines,
-	}.String())
+	})
 }
 
 // Error creates an error with the level "error".
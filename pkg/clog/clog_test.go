@@ -1,12 +1,15 @@
 package clog
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"cdr.dev/slog/sloggers/slogtest/assert"
+	"github.com/fatih/color"
 	"golang.org/x/xerrors"
 )
 
@@ -74,3 +77,92 @@ func TestError(t *testing.T) {
 		)
 	})
 }
+
+func TestQuiet(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	assert.Success(t, "create pipe", err)
+
+	//! clearly not thread safe
+	os.Stderr = writer
+
+	SetQuiet(true)
+	defer SetQuiet(false)
+	LogInfo("info message")
+	LogSuccess("success message")
+	LogWarn("warning message")
+	Log(Error("error message"))
+	writer.Close()
+
+	output, err := ioutil.ReadAll(reader)
+	assert.Success(t, "read all stderr output", err)
+
+	assert.Equal(t, "output is as expected", "warning: warning message\nerror: error message\n\n", string(output))
+}
+
+func TestJSONFormat(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	assert.Success(t, "create pipe", err)
+
+	//! clearly not thread safe
+	os.Stderr = writer
+
+	assert.Success(t, "set json format", SetFormat(FormatJSON))
+	defer func() { _ = SetFormat(FormatHuman) }()
+	LogInfo("info message")
+	LogSuccess("success message", "detail")
+	LogWarn("warning message")
+	Log(Error("error message", Tipf("content of fake tip")))
+	Log(xerrors.New("plain error"))
+	writer.Close()
+
+	output, err := ioutil.ReadAll(reader)
+	assert.Success(t, "read all stderr output", err)
+
+	lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	expected := []map[string]interface{}{
+		{"level": "info", "message": "info message"},
+		{"level": "success", "message": "success message", "lines": []interface{}{"detail"}},
+		{"level": "warning", "message": "warning message"},
+		{"level": "error", "message": "error message", "lines": []interface{}{"tip: content of fake tip"}},
+		{"level": "fatal", "message": "plain error"},
+	}
+	assert.Equal(t, "line count", len(expected), len(lines))
+	for i, line := range lines {
+		var msg map[string]interface{}
+		assert.Success(t, "valid json", json.Unmarshal([]byte(line), &msg))
+		assert.Equal(t, "json message", expected[i], msg)
+	}
+
+	assert.Error(t, "unknown format", SetFormat("xml"))
+}
+
+func TestColor(t *testing.T) {
+	logWarn := func() string {
+		reader, writer, err := os.Pipe()
+		assert.Success(t, "create pipe", err)
+
+		//! clearly not thread safe
+		os.Stderr = writer
+
+		LogWarn("warning message", Tipf("content of fake tip"))
+		writer.Close()
+
+		output, err := ioutil.ReadAll(reader)
+		assert.Success(t, "read all stderr output", err)
+		return string(output)
+	}
+
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+
+	// Pretend we are writing to a terminal.
+	color.NoColor = false
+	assert.True(t, "colored output has escape sequences", strings.Contains(logWarn(), "\x1b["))
+
+	DisableColor()
+	assert.True(t, "uncolored output has no escape sequences", !strings.Contains(logWarn(), "\x1b["))
+
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+	assert.True(t, "NO_COLOR disables colors", !colorSupported())
+}
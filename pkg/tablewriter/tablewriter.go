@@ -1,42 +1,265 @@
 package tablewriter
 
 import (
+	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strings"
 	"text/tabwriter"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 const structFieldTagKey = "table"
 
+// headerTagOption is the table tag option setting the header of a field, which
+// may contain spaces, e.g. `table:"header=Access Level"`.
+const headerTagOption = "header="
+
+// alignRightTagOption is the table tag option right aligning the column of a field, e.g. `table:"Port,right"`.
+const alignRightTagOption = "right"
+
+// Option customizes the output of WriteTable.
+type Option func(*options)
+
+type options struct {
+	// shown holds the Go identifiers of hidden fields which should be displayed anyways.
+	shown map[string]bool
+	// showAll displays every hidden field.
+	showAll bool
+	// width is the maximum width of the table, unlimited when not positive.
+	width int
+	// widthSet is set when width was given, rather than being the one of the terminal.
+	widthSet bool
+	// noHeaders omits the header row.
+	noHeaders bool
+	// fields holds the headers of the only fields to display, in order, when set.
+	fields []string
+	// out is where the table is written.
+	out io.Writer
+}
+
+// columnPadding is the number of spaces between two columns.
+const columnPadding = 4
+
+// minColumnWidth is the width below which a column is not truncated to fit the table width.
+const minColumnWidth = 12
+
+// ellipsis replaces the middle of the values truncated to fit the table width.
+const ellipsis = "…"
+
+// ShowHidden displays the given fields, identified by their Go identifier,
+// even though they are tagged `table:"-"`.
+func ShowHidden(fields ...string) Option {
+	return func(o *options) {
+		for _, f := range fields {
+			o.shown[f] = true
+		}
+	}
+}
+
+// ShowAllHidden displays every field, including the ones tagged `table:"-"`.
+func ShowAllHidden() Option {
+	return func(o *options) {
+		o.showAll = true
+	}
+}
+
+// Width truncates the values of the widest columns so that the table fits in the given width,
+// overriding the width of the terminal. A width of 0 disables truncation.
+func Width(width int) Option {
+	return func(o *options) {
+		o.width, o.widthSet = width, true
+	}
+}
+
+// Output writes the table to w instead of stdout. Values are only truncated to fit the width
+// of the terminal when w is stdout.
+func Output(w io.Writer) Option {
+	return func(o *options) {
+		o.out = w
+	}
+}
+
+// NoHeaders omits the header row, e.g. for processing the table with other tools.
+func NoHeaders() Option {
+	return func(o *options) {
+		o.noHeaders = true
+	}
+}
+
+// Fields only displays the given fields, in the given order, including hidden ones.
+// Fields are identified by their header, ignoring case.
+func Fields(headers ...string) Option {
+	return func(o *options) {
+		o.fields = headers
+	}
+}
+
+// FieldNames returns the headers of every field of the given struct, including hidden ones,
+// as accepted by Fields.
+func FieldNames(data interface{}) []string {
+	return structFieldNameCells(data, &options{showAll: true})
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{shown: map[string]bool{}, out: os.Stdout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if !o.widthSet && o.out == os.Stdout {
+		o.width = terminalWidth()
+	}
+	return o
+}
+
 // StructValues tab delimits the values of a given struct.
 //
 // Tag a field `table:"-"` to hide it from output.
-func StructValues(data interface{}) string {
+// Untagged embedded structs are flattened into the parent.
+func StructValues(data interface{}, opts ...Option) string {
+	return structValues(data, newOptions(opts))
+}
+
+func structValues(data interface{}, o *options) string {
+	// Unknown fields are left out, as they can't be reported.
+	indices, _ := o.fieldIndices(data)
+	return joinCells(o.columns(structValueCells, data, indices))
+}
+
+func structValueCells(data interface{}, o *options) []string {
 	v := reflect.ValueOf(data)
-	s := &strings.Builder{}
+	var cells []string
 	for i := 0; i < v.NumField(); i++ {
-		if shouldHideField(v.Type().Field(i)) {
+		field := v.Type().Field(i)
+		if o.shouldHideField(field) {
+			continue
+		}
+		if shouldFlattenField(field) {
+			cells = append(cells, structValueCells(v.Field(i).Interface(), o)...)
 			continue
 		}
-		fmt.Fprintf(s, "%v\t", v.Field(i).Interface())
+		if f := v.Field(i); f.Kind() == reflect.Ptr {
+			// Leave unset values blank, and show the ones which are set rather than their address.
+			if f.IsNil() {
+				cells = append(cells, "")
+			} else {
+				cells = append(cells, fmt.Sprintf("%v", f.Elem().Interface()))
+			}
+			continue
+		}
+		cells = append(cells, fmt.Sprintf("%v", v.Field(i).Interface()))
 	}
-	return s.String()
+	return cells
 }
 
 // StructFieldNames tab delimits the field names of a given struct.
 //
 // Tag a field `table:"-"` to hide it from output.
-func StructFieldNames(data interface{}) string {
+// Untagged embedded structs are flattened into the parent.
+func StructFieldNames(data interface{}, opts ...Option) string {
+	return structFieldNames(data, newOptions(opts))
+}
+
+func structFieldNames(data interface{}, o *options) string {
+	indices, _ := o.fieldIndices(data)
+	return joinCells(o.columns(structFieldNameCells, data, indices))
+}
+
+func structFieldNameCells(data interface{}, o *options) []string {
 	v := reflect.ValueOf(data)
-	s := &strings.Builder{}
+	var cells []string
 	for i := 0; i < v.NumField(); i++ {
 		field := v.Type().Field(i)
-		if shouldHideField(field) {
+		if o.shouldHideField(field) {
 			continue
 		}
-		fmt.Fprintf(s, "%s\t", fieldName(field))
+		if shouldFlattenField(field) {
+			cells = append(cells, structFieldNameCells(v.Field(i).Interface(), o)...)
+			continue
+		}
+		cells = append(cells, fieldName(field))
+	}
+	return cells
+}
+
+func structFieldAlignments(data interface{}, o *options) []bool {
+	v := reflect.ValueOf(data)
+	var right []bool
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if o.shouldHideField(field) {
+			continue
+		}
+		if shouldFlattenField(field) {
+			right = append(right, structFieldAlignments(v.Field(i).Interface(), o)...)
+			continue
+		}
+		right = append(right, hasTagOption(field, alignRightTagOption))
+	}
+	return right
+}
+
+// fieldIndices returns the indices of the fields selected by o among every field of data, in order.
+// Unknown fields are an error, and are left out of the returned indices.
+func (o *options) fieldIndices(data interface{}) ([]int, error) {
+	if o.fields == nil {
+		return nil, nil
+	}
+	headers := FieldNames(data)
+	indices := make([]int, 0, len(o.fields))
+	var err error
+	for _, field := range o.fields {
+		found := false
+		for i, header := range headers {
+			if strings.EqualFold(header, field) {
+				indices, found = append(indices, i), true
+				break
+			}
+		}
+		if !found && err == nil {
+			err = fmt.Errorf("unknown field %q, valid fields are %q", field, headers)
+		}
+	}
+	return indices, err
+}
+
+// columns returns the cells given by cellsOf for the displayed fields of data. When fields are
+// selected, the cells are the ones at the given indices among the cells of every field.
+func (o *options) columns(cellsOf func(interface{}, *options) []string, data interface{}, indices []int) []string {
+	if o.fields == nil {
+		return cellsOf(data, o)
+	}
+	all := cellsOf(data, &options{showAll: true})
+	cells := make([]string, 0, len(indices))
+	for _, i := range indices {
+		cells = append(cells, all[i])
+	}
+	return cells
+}
+
+// alignments returns the alignments of the columns of data, like columns.
+func (o *options) alignments(data interface{}, indices []int) []bool {
+	if o.fields == nil {
+		return structFieldAlignments(data, o)
+	}
+	all := structFieldAlignments(data, &options{showAll: true})
+	right := make([]bool, 0, len(indices))
+	for _, i := range indices {
+		right = append(right, all[i])
+	}
+	return right
+}
+
+// joinCells tab delimits the given cells, terminating each of them with a tab.
+func joinCells(cells []string) string {
+	s := &strings.Builder{}
+	for _, cell := range cells {
+		s.WriteString(cell)
+		s.WriteString("\t")
 	}
 	return s.String()
 }
@@ -45,34 +268,200 @@ func StructFieldNames(data interface{}) string {
 // tabular format. Headers abide by the `table` struct tag.
 //
 // `table:"-"` omits the field and no tag defaults to the Go identifier.
-func WriteTable(length int, each func(i int) interface{}) error {
+// Columns are left aligned, unless tagged with the right option, e.g. `table:"Port,right"`.
+// The header option overrides the displayed header, e.g. `table:"Access,header=Access Level"`.
+// Passing Fields selects and orders the columns, unknown fields being an error.
+// When stdout is a terminal, the middle of over-long values is elided so that the table fits its width.
+func WriteTable(length int, each func(i int) interface{}, opts ...Option) error {
+	o := newOptions(opts)
+	return writeTable(o.out, length, each, o)
+}
+
+func writeTable(out io.Writer, length int, each func(i int) interface{}, o *options) error {
 	if length < 1 {
 		return nil
 	}
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
-	defer func() { _ = w.Flush() }() // Best effort.
+	indices, err := o.fieldIndices(each(0))
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, length+1)
 	for ix := 0; ix < length; ix++ {
 		item := each(ix)
 		if ix == 0 {
-			if _, err := fmt.Fprintln(w, StructFieldNames(item)); err != nil {
+			rows = append(rows, o.columns(structFieldNameCells, item, indices))
+		}
+		rows = append(rows, o.columns(structValueCells, item, indices))
+	}
+	if o.width > 0 {
+		truncateRows(rows, o.width)
+	}
+	alignRight(rows, o.alignments(each(0), indices))
+	if o.noHeaders {
+		rows = rows[1:]
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, columnPadding, ' ', 0)
+	defer func() { _ = w.Flush() }() // Best effort.
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(w, joinCells(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes the given list elements to stdout as RFC 4180 CSV, with the same columns as WriteTable.
+// Values are never truncated nor aligned.
+func WriteCSV(length int, each func(i int) interface{}, opts ...Option) error {
+	o := newOptions(opts)
+	return writeCSV(o.out, length, each, o)
+}
+
+func writeCSV(out io.Writer, length int, each func(i int) interface{}, o *options) error {
+	if length < 1 {
+		return nil
+	}
+	indices, err := o.fieldIndices(each(0))
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(out)
+	for ix := 0; ix < length; ix++ {
+		item := each(ix)
+		if ix == 0 && !o.noHeaders {
+			if err := w.Write(o.columns(structFieldNameCells, item, indices)); err != nil {
 				return err
 			}
 		}
-		if _, err := fmt.Fprintln(w, StructValues(item)); err != nil {
+		if err := w.Write(o.columns(structValueCells, item, indices)); err != nil {
 			return err
 		}
 	}
-	return nil
+	w.Flush()
+	return w.Error()
+}
+
+// truncateRows elides the middle of the values of the widest columns until the rows fit in width.
+// The first row holds the headers, which are never truncated.
+func truncateRows(rows [][]string, width int) {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	total := 0
+	for _, w := range widths {
+		total += w + columnPadding
+	}
+
+	for total > width {
+		// Shrink the widest column which can still be truncated.
+		widest := -1
+		for i, w := range widths {
+			limit := minColumnWidth
+			if n := utf8.RuneCountInString(rows[0][i]); n > limit {
+				limit = n
+			}
+			if w > limit && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		widths[widest]--
+		total--
+	}
+
+	for _, row := range rows[1:] {
+		for i := range row {
+			row[i] = elide(row[i], widths[i])
+		}
+	}
+}
+
+// alignRight pads the cells of the columns flagged in right with leading spaces, so that they all
+// have the same width and end up right aligned once laid out by the tabwriter.
+func alignRight(rows [][]string, right []bool) {
+	for i, r := range right {
+		if !r {
+			continue
+		}
+		width := 0
+		for _, row := range rows {
+			if n := utf8.RuneCountInString(row[i]); n > width {
+				width = n
+			}
+		}
+		for _, row := range rows {
+			row[i] = strings.Repeat(" ", width-utf8.RuneCountInString(row[i])) + row[i]
+		}
+	}
+}
+
+// elide replaces the middle of s with an ellipsis so that it is at most width runes long.
+func elide(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	keep := width - utf8.RuneCountInString(ellipsis)
+	head, tail := (keep+1)/2, keep/2
+	return string(r[:head]) + ellipsis + string(r[len(r)-tail:])
+}
+
+// terminalWidth returns the width of the terminal attached to stdout, or 0 if there is none.
+func terminalWidth() int {
+	width, _, err := terminal.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// parseTag splits the table tag of a field into its name and options.
+// The name may be omitted when the tag starts with an option, e.g. `table:"header=Access Level"`.
+func parseTag(f reflect.StructField) (name string, opts []string) {
+	tag := strings.Split(f.Tag.Get(structFieldTagKey), ",")
+	if strings.Contains(tag[0], "=") {
+		return "", tag
+	}
+	return tag[0], tag[1:]
+}
+
+func hasTagOption(f reflect.StructField, option string) bool {
+	_, opts := parseTag(f)
+	for _, opt := range opts {
+		if opt == option {
+			return true
+		}
+	}
+	return false
 }
 
 func fieldName(f reflect.StructField) string {
-	custom, ok := f.Tag.Lookup(structFieldTagKey)
-	if ok {
+	custom, opts := parseTag(f)
+	for _, opt := range opts {
+		if strings.HasPrefix(opt, headerTagOption) {
+			return strings.TrimPrefix(opt, headerTagOption)
+		}
+	}
+	if custom != "" && custom != "-" {
 		return custom
 	}
 	return f.Name
 }
 
-func shouldHideField(f reflect.StructField) bool {
-	return f.Tag.Get(structFieldTagKey) == "-"
+func (o *options) shouldHideField(f reflect.StructField) bool {
+	name, _ := parseTag(f)
+	return name == "-" && !o.showAll && !o.shown[f.Name]
+}
+
+func shouldFlattenField(f reflect.StructField) bool {
+	_, tagged := f.Tag.Lookup(structFieldTagKey)
+	return f.Anonymous && f.Type.Kind() == reflect.Struct && !tagged
 }
@@ -0,0 +1,169 @@
+package tablewriter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"cdr.dev/slog/sloggers/slogtest/assert"
+)
+
+type testRow struct {
+	Name string `table:"Name"`
+	URL  string `table:"URL"`
+	ID   string `table:"-"`
+}
+
+func TestWriteTableWidth(t *testing.T) {
+	t.Parallel()
+
+	rows := []testRow{
+		{Name: "web", URL: "web-abcdefghijklmnopqrstuvwxyz.coder.com", ID: "hidden"},
+		{Name: "api", URL: "api.coder.com"},
+	}
+	write := func(opts ...Option) string {
+		var out bytes.Buffer
+		err := writeTable(&out, len(rows), func(i int) interface{} { return rows[i] }, newOptions(opts))
+		assert.Success(t, "write table", err)
+		return out.String()
+	}
+
+	assert.Equal(t, "full table", strings.Join([]string{
+		"Name    URL                                         ",
+		"web     web-abcdefghijklmnopqrstuvwxyz.coder.com    ",
+		"api     api.coder.com                               ",
+		"",
+	}, "\n"), write(Width(0)))
+
+	truncated := write(Width(32))
+	assert.Equal(t, "truncated table", strings.Join([]string{
+		"Name    URL                     ",
+		"web     web-abcdef…coder.com    ",
+		"api     api.coder.com           ",
+		"",
+	}, "\n"), truncated)
+}
+
+func TestElide(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "short value", "coder.com", elide("coder.com", 12))
+	assert.Equal(t, "long value", "abcd…wxyz", elide("abcdefghijklmnopqrstuvwxyz", 9))
+	assert.Equal(t, "even width", "abc…yz", elide("abcdefghijklmnopqrstuvwxyz", 6))
+}
+
+func TestWriteTableAlignment(t *testing.T) {
+	t.Parallel()
+
+	type portRow struct {
+		URL  string `table:"URL"`
+		Port int    `table:"Port,right"`
+		Name string `table:",right"`
+	}
+	rows := []portRow{
+		{URL: "web.coder.com", Port: 8080, Name: "web"},
+		{URL: "api.coder.com", Port: 80, Name: "frontend"},
+	}
+
+	var out bytes.Buffer
+	err := writeTable(&out, len(rows), func(i int) interface{} { return rows[i] }, newOptions([]Option{Width(0)}))
+	assert.Success(t, "write table", err)
+	assert.Equal(t, "aligned table", strings.Join([]string{
+		"URL              Port        Name    ",
+		"web.coder.com    8080         web    ",
+		"api.coder.com      80    frontend    ",
+		"",
+	}, "\n"), out.String())
+}
+
+func TestStructFieldNamesHeader(t *testing.T) {
+	t.Parallel()
+
+	type headerRow struct {
+		Access string `table:"header=Access Level"`
+		Level  string `table:"Level,right,header=Max Level"`
+		Hidden string `table:"-,header=Hidden"`
+		Name   string `table:"Name"`
+		Other  string
+	}
+	assert.Equal(t, "field names", "Access Level\tMax Level\tName\tOther\t", StructFieldNames(headerRow{}))
+	assert.Equal(t, "hidden field names", "Access Level\tMax Level\tHidden\tName\tOther\t", StructFieldNames(headerRow{}, ShowAllHidden()))
+}
+
+func TestWriteTableNoHeaders(t *testing.T) {
+	t.Parallel()
+
+	rows := []testRow{{Name: "web", URL: "web.coder.com"}, {Name: "api", URL: "api.coder.com"}}
+	var out bytes.Buffer
+	err := writeTable(&out, len(rows), func(i int) interface{} { return rows[i] }, newOptions([]Option{Width(0), NoHeaders()}))
+	assert.Success(t, "write table", err)
+	assert.Equal(t, "table without headers", "web    web.coder.com    \napi    api.coder.com    \n", out.String())
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Parallel()
+
+	rows := []testRow{{Name: "web, frontend", URL: "web.coder.com", ID: "web-id"}, {Name: `"api"`, URL: "api.coder.com", ID: "api-id"}}
+	write := func(opts ...Option) string {
+		var out bytes.Buffer
+		err := writeCSV(&out, len(rows), func(i int) interface{} { return rows[i] }, newOptions(opts))
+		assert.Success(t, "write csv", err)
+		return out.String()
+	}
+
+	assert.Equal(t, "csv", "Name,URL\n\"web, frontend\",web.coder.com\n\"\"\"api\"\"\",api.coder.com\n", write())
+	assert.Equal(t, "csv with hidden fields", "Name,URL,ID\n\"web, frontend\",web.coder.com,web-id\n\"\"\"api\"\"\",api.coder.com,api-id\n", write(ShowAllHidden()))
+	assert.Equal(t, "csv without headers", "\"web, frontend\",web.coder.com\n\"\"\"api\"\"\",api.coder.com\n", write(NoHeaders()))
+}
+
+func TestWriteTableOutput(t *testing.T) {
+	t.Parallel()
+
+	rows := []testRow{{Name: "web", URL: "web-abcdefghijklmnopqrstuvwxyz.coder.com"}}
+	each := func(i int) interface{} { return rows[i] }
+
+	var out bytes.Buffer
+	assert.Success(t, "write table", WriteTable(len(rows), each, Output(&out)))
+	assert.True(t, "not truncated", strings.Contains(out.String(), rows[0].URL))
+
+	out.Reset()
+	assert.Success(t, "write truncated table", WriteTable(len(rows), each, Output(&out), Width(32)))
+	assert.True(t, "explicit width", !strings.Contains(out.String(), rows[0].URL))
+
+	out.Reset()
+	assert.Success(t, "write csv", WriteCSV(len(rows), each, Output(&out)))
+	assert.Equal(t, "csv", "Name,URL\nweb,"+rows[0].URL+"\n", out.String())
+}
+
+func TestStructValuesPointers(t *testing.T) {
+	t.Parallel()
+
+	type pointerRow struct {
+		Name  *string `table:"Name"`
+		Count *int    `table:"Count"`
+	}
+	name := "web"
+	assert.Equal(t, "pointer values", "web\t\t", StructValues(pointerRow{Name: &name}))
+}
+
+func TestWriteTableFields(t *testing.T) {
+	t.Parallel()
+
+	rows := []testRow{{Name: "web", URL: "web.coder.com", ID: "web-id"}}
+	each := func(i int) interface{} { return rows[i] }
+
+	var out bytes.Buffer
+	err := writeTable(&out, len(rows), each, newOptions([]Option{Width(0), Fields("id", "Name")}))
+	assert.Success(t, "write table", err)
+	assert.Equal(t, "selected fields", "ID        Name    \nweb-id    web     \n", out.String())
+
+	out.Reset()
+	err = writeCSV(&out, len(rows), each, newOptions([]Option{Fields("url", "ID")}))
+	assert.Success(t, "write csv", err)
+	assert.Equal(t, "selected csv fields", "URL,ID\nweb.coder.com,web-id\n", out.String())
+
+	err = writeTable(&out, len(rows), each, newOptions([]Option{Fields("name", "owner")}))
+	assert.ErrorContains(t, "unknown field", err, `unknown field "owner"`)
+
+	assert.Equal(t, "field names", []string{"Name", "URL", "ID"}, FieldNames(testRow{}))
+}